@@ -1,15 +1,21 @@
 package main
 
 import (
-	"bufio"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"regexp"
+	"runtime"
 	"strconv"
+	"strings"
+	"sync/atomic"
 
 	au "github.com/logrusorgru/aurora"
 	"github.com/tanishiking/jpp"
+	"github.com/tidwall/gjson"
 	"golang.org/x/crypto/ssh/terminal"
 )
 
@@ -19,15 +25,17 @@ var (
 	defaultNumber    = jpp.NoColor
 	defaultString    = jpp.Green
 	defaultFieldName = jpp.BoldBlue
+	defaultMarker    = jpp.Magenta
 )
 
 var (
-	defaultCLIScheme = &jpp.ColorScheme{
-		Null:      getColor("JPP_NULL", defaultNull),
-		Bool:      getColor("JPP_BOOL", defaultBool),
-		Number:    getColor("JPP_NUMBER", defaultNumber),
-		String:    getColor("JPP_STRING", defaultString),
-		FieldName: getColor("JPP_FIELDNAME", defaultFieldName),
+	colorEnvVars = []string{
+		"JPP_NULL",
+		"JPP_BOOL",
+		"JPP_NUMBER",
+		"JPP_STRING",
+		"JPP_FIELDNAME",
+		"JPP_MARKER",
 	}
 	monochrome = &jpp.ColorScheme{
 		Null:      jpp.NoColor,
@@ -35,9 +43,36 @@ var (
 		Number:    jpp.NoColor,
 		String:    jpp.NoColor,
 		FieldName: jpp.NoColor,
+		Marker:    jpp.NoColor,
 	}
 )
 
+// defaultCLIScheme builds the color scheme for the CLI from JPP_* variables.
+// If none of them are set, JQ_COLORS is used instead so that jpp looks
+// the same as jq.
+func defaultCLIScheme() *jpp.ColorScheme {
+	for _, envvar := range colorEnvVars {
+		if os.Getenv(envvar) != "" {
+			return envScheme()
+		}
+	}
+	if scheme, ok := parseJQColors(os.Getenv("JQ_COLORS"), envScheme()); ok {
+		return scheme
+	}
+	return envScheme()
+}
+
+func envScheme() *jpp.ColorScheme {
+	return &jpp.ColorScheme{
+		Null:      getColor("JPP_NULL", defaultNull),
+		Bool:      getColor("JPP_BOOL", defaultBool),
+		Number:    getColor("JPP_NUMBER", defaultNumber),
+		String:    getColor("JPP_STRING", defaultString),
+		FieldName: getColor("JPP_FIELDNAME", defaultFieldName),
+		Marker:    getColor("JPP_MARKER", defaultMarker),
+	}
+}
+
 func getColor(envvar string, fallback jpp.ColoredFormat) jpp.ColoredFormat {
 	v := os.Getenv(envvar)
 	if v != "" {
@@ -92,17 +127,84 @@ func getColor(envvar string, fallback jpp.ColoredFormat) jpp.ColoredFormat {
 	return fallback
 }
 
+// defaultFileWidth is the width used for --write, --check and --diff
+// when -w is not given, so that the result doesn't depend on the terminal.
+const defaultFileWidth = 80
+
+const (
+	formatText = "text"
+	formatHTML = "html"
+	formatSVG  = "svg"
+)
+
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// shouldColor decides whether the output should be colored.
+// An explicit "always" or "never" wins over everything else.
+// In "auto" mode, NO_COLOR (https://no-color.org) disables colors,
+// FORCE_COLOR enables them, and otherwise we color only if the
+// output is a terminal.
+func shouldColor(mode string, isTerminal bool) (bool, error) {
+	switch mode {
+	case colorAlways:
+		return true, nil
+	case colorNever:
+		return false, nil
+	case colorAuto:
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		if v := os.Getenv("FORCE_COLOR"); v != "" && v != "0" && v != "false" {
+			return true, nil
+		}
+		return isTerminal, nil
+	default:
+		return false, fmt.Errorf("invalid --color value %q: must be auto, always or never", mode)
+	}
+}
+
+// stringsFlag is a flag that can be given multiple times.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
 type cli struct {
 	inStream             io.Reader
 	outStream, errStream io.Writer
 }
 
 func (c *cli) run(args []string) int {
+	if len(args) > 1 && args[1] == "lsp" {
+		return c.runLSP(args[2:])
+	}
+	if len(args) > 1 && args[1] == "schema" {
+		return c.runSchema(args[2:])
+	}
+	if len(args) > 1 && args[1] == "diff" {
+		return c.runDiff(args[2:])
+	}
+	if len(args) > 1 && args[1] == "stats" {
+		return c.runStats(args[2:])
+	}
+
 	var termErr error
 	termWidth := -1
+	isTerminal := false
 	f, ok := c.outStream.(*os.File)
 	if ok {
 		fd := int(f.Fd())
+		isTerminal = terminal.IsTerminal(fd)
 		terminalWidth, _, termErr := terminal.GetSize(fd)
 		if termErr == nil {
 			termWidth = terminalWidth
@@ -110,49 +212,437 @@ func (c *cli) run(args []string) int {
 	}
 
 	var (
-		indent  string
-		width   int
-		noColor bool
+		indent     string
+		width      int
+		noColor    bool
+		colorMode  string
+		format     string
+		standalone bool
+		css        bool
+		padding    int
+		mode       fileMode
+		exts       string
+		includes   stringsFlag
+		excludes   stringsFlag
+		noIgnore   bool
+		jobs       int
+		sortKeys   bool
+		layoutName string
+		noConfig   bool
+		stdinFile  string
+		detect     bool
+		tabWidth   int
+		paths      bool
+		pathStyle  string
+		lineNums   bool
+		foldsOut   bool
+		gron       bool
+		ungron     bool
+		shape      bool
+		counts     bool
+		schemaFile string
+		highlightP string
+		grepP      string
+		context    int
+		redact     redactFlags
+		expand     bool
+		expandMax  int
+		unwrapStr  bool
 	)
 
 	flags := flag.NewFlagSet("jpp", flag.ContinueOnError)
 	flags.SetOutput(c.errStream)
 	flags.IntVar(&width, "w", termWidth, "width")
 	flags.StringVar(&indent, "i", "  ", "indentation")
-	flags.BoolVar(&noColor, "no-color", false, "disable the output color")
+	flags.IntVar(&tabWidth, "tab-width", jpp.DefaultTabWidth, "display width of a tab in the indentation")
+	flags.BoolVar(&noColor, "no-color", false, "disable the output color (same as --color=never)")
+	flags.StringVar(&colorMode, "color", colorAuto, "when to color the output: auto, always or never")
+	flags.StringVar(&format, "format", formatText, "output format: text, html or svg")
+	flags.BoolVar(&standalone, "standalone", false, "render a whole HTML page with collapsible containers (--format html)")
+	flags.BoolVar(&css, "css", false, "print the style sheet of --format html generated from the colors instead of formatting")
+	flags.IntVar(&padding, "padding", 16, "padding around the text in pixels (--format svg)")
+	flags.BoolVar(&mode.write, "write", false, "write the result to the source files instead of stdout")
+	flags.BoolVar(&mode.check, "check", false, "list files whose formatting differs from jpp's and exit with 1")
+	flags.BoolVar(&mode.diff, "diff", false, "display diffs instead of rewriting files")
+	flags.StringVar(&exts, "ext", ".json", "comma-separated extensions of the files to format in directories")
+	flags.Var(&includes, "include", "glob pattern of the files to format in directories (repeatable)")
+	flags.Var(&excludes, "exclude", "glob pattern of the files to skip in directories (repeatable)")
+	flags.BoolVar(&noIgnore, "no-ignore", false, "don't skip the files ignored by .gitignore")
+	flags.IntVar(&jobs, "jobs", runtime.NumCPU(), "number of files formatted in parallel")
+	flags.BoolVar(&sortKeys, "sort-keys", false, "sort the members of objects by their keys")
+	flags.StringVar(&layoutName, "layout", jpp.LayoutCompact.String(), "layout strategy: compact or expanded")
+	flags.BoolVar(&detect, "detect-indent", false, "keep the indentation and the final newline of the input")
+	flags.BoolVar(&paths, "paths", false, "print the JSON path of each line in a gutter")
+	flags.StringVar(&pathStyle, "path-style", pathStyleDotted, "notation of the paths in the gutter: dotted or pointer")
+	flags.BoolVar(&lineNums, "line-numbers", false, "print line numbers in a gutter")
+	flags.BoolVar(&foldsOut, "folds", false, "print the line ranges of the containers as JSON Lines instead of the formatted text")
+	flags.BoolVar(&gron, "gron", false, "print each value as an assignment such as json.items[0].name = \"foo\";")
+	flags.BoolVar(&ungron, "ungron", false, "rebuild the JSON from the assignments of --gron")
+	flags.BoolVar(&unwrapStr, "unwrap", false, "decode the top-level string repeatedly and format the JSON inside it")
+	flags.BoolVar(&shape, "shape", false, "print the types of the values instead of the values, merging the elements of arrays")
+	flags.BoolVar(&counts, "counts", false, "print the number of elements of arrays (--shape)")
+	flags.StringVar(&schemaFile, "schema", "", "validate the input against the JSON Schema file (draft 7 or 2020-12)")
+	flags.StringVar(&highlightP, "highlight", "", "emphasize the keys and strings matching the regular expression")
+	flags.StringVar(&grepP, "grep", "", "print only the values whose keys or values match the regular expression, with their paths")
+	flags.IntVar(&context, "context", 0, "number of the levels of parents printed with the matches of --grep")
+	redact.register(flags)
+	flags.BoolVar(&expand, "expand-strings", false, "print the strings that encode JSON objects or arrays as nested values marked with @json")
+	flags.IntVar(&expandMax, "expand-depth", 3, "maximum levels of strings in strings expanded by --expand-strings")
+	flags.BoolVar(&noConfig, "no-config", false, "don't read .jpp.json, .jpp.toml and .editorconfig")
+	flags.StringVar(&stdinFile, "stdin-filename", "", "path of the standard input used to find the configuration, which isn't read without it")
 	err := flags.Parse(args[1:])
 	if err != nil {
 		return 1
 	}
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	layout, err := jpp.ParseLayout(layoutName)
+	if err != nil {
+		fmt.Fprintln(c.errStream, err.Error())
+		return 1
+	}
+	if format != formatText && format != formatHTML && format != formatSVG {
+		fmt.Fprintf(c.errStream, "invalid --format value %q: must be text, html or svg\n", format)
+		return 1
+	}
+	if pathStyle != pathStyleDotted && pathStyle != pathStylePointer {
+		fmt.Fprintf(c.errStream, "invalid --path-style value %q: must be dotted or pointer\n", pathStyle)
+		return 1
+	}
+	if (paths || lineNums || foldsOut) && (format != formatText || mode.enabled()) {
+		fmt.Fprintln(c.errStream, "--paths, --line-numbers and --folds can be used only with --format text and without --write, --check and --diff")
+		return 1
+	}
+	if gron && ungron {
+		fmt.Fprintln(c.errStream, "--gron and --ungron cannot be used together")
+		return 1
+	}
+	if (gron && (format != formatText || paths || lineNums || foldsOut)) || ((gron || ungron) && mode.enabled()) {
+		fmt.Fprintln(c.errStream, "--gron can be used only with --format text, and --gron and --ungron cannot be used with --write, --check and --diff")
+		return 1
+	}
+	if shape && (format != formatText || mode.enabled() || gron || paths || lineNums || foldsOut) {
+		fmt.Fprintln(c.errStream, "--shape can be used only with --format text, and cannot be used with --write, --check, --diff, --gron, --paths, --line-numbers and --folds")
+		return 1
+	}
+	if schemaFile != "" && (format != formatText || mode.enabled() || gron || shape || foldsOut) {
+		fmt.Fprintln(c.errStream, "--schema can be used only with --format text, and cannot be used with --write, --check, --diff, --gron, --shape and --folds")
+		return 1
+	}
+	if highlightP != "" && format != formatText {
+		fmt.Fprintln(c.errStream, "--highlight can be used only with --format text")
+		return 1
+	}
+	if grepP != "" && (format != formatText || mode.enabled() || gron || ungron || shape || paths || lineNums || foldsOut || schemaFile != "") {
+		fmt.Fprintln(c.errStream, "--grep can be used only with --format text, and cannot be used with --write, --check, --diff, --gron, --ungron, --shape, --paths, --line-numbers, --folds and --schema")
+		return 1
+	}
+	var highlightRe, grepRe *regexp.Regexp
+	if highlightP != "" {
+		highlightRe, err = regexp.Compile(highlightP)
+		if err != nil {
+			fmt.Fprintf(c.errStream, "invalid --highlight value: %v\n", err)
+			return 1
+		}
+	}
+	if grepP != "" {
+		grepRe, err = regexp.Compile(grepP)
+		if err != nil {
+			fmt.Fprintf(c.errStream, "invalid --grep value: %v\n", err)
+			return 1
+		}
+		// The matches are emphasized unless --highlight is given.
+		if highlightRe == nil {
+			highlightRe = grepRe
+		}
+	}
+	redaction, err := redact.redaction()
+	if err != nil {
+		fmt.Fprintln(c.errStream, err.Error())
+		return 1
+	}
+	if redaction != nil && mode.enabled() {
+		fmt.Fprintln(c.errStream, "--redact cannot be used with --write, --check and --diff")
+		return 1
+	}
+	if unwrapStr && (ungron || mode.enabled()) {
+		fmt.Fprintln(c.errStream, "--unwrap cannot be used with --ungron, --write, --check and --diff")
+		return 1
+	}
+	if css && mode.enabled() {
+		fmt.Fprintln(c.errStream, "--css cannot be used with --write, --check and --diff")
+		return 1
+	}
+	if expand && mode.enabled() {
+		fmt.Fprintln(c.errStream, "--expand-strings cannot be used with --write, --check and --diff")
+		return 1
+	}
+	var validator *schemaValidator
+	if schemaFile != "" {
+		validator, err = loadSchema(schemaFile)
+		if err != nil {
+			fmt.Fprintln(c.errStream, err.Error())
+			return 1
+		}
+	}
+	if noColor {
+		colorMode = colorNever
+	}
+	// Markup formats and style sheets don't contain escape sequences,
+	// so they are colored in auto mode wherever they are written.
+	useColor, err := shouldColor(colorMode, isTerminal || format != formatText || css)
+	if err != nil {
+		fmt.Fprintln(c.errStream, err.Error())
+		return 1
+	}
+
+	if mode.enabled() {
+		if format != formatText {
+			fmt.Fprintln(c.errStream, "--write, --check and --diff can be used only with --format text")
+			return 1
+		}
+		// Formatted files must not depend on the terminal.
+		useColor = false
+		if !set["w"] {
+			width = defaultFileWidth
+		}
+	}
 
 	if termErr != nil && width < 0 {
 		fmt.Fprintln(c.errStream, "Couldn't read terminal width from your terminal.")
 		return 1
 	}
 
-	reader := bufio.NewReader(c.inStream)
-	var output []rune
-	for {
-		input, _, err := reader.ReadRune()
-		if err != nil && err == io.EOF {
-			break
+	var colorScheme *jpp.ColorScheme
+	gutterColor := jpp.NoColor
+	errorColor := jpp.NoColor
+	if useColor {
+		colorScheme = defaultCLIScheme()
+		gutterColor = jpp.SGR("2")
+		errorColor = jpp.SGR("31")
+	} else {
+		colorScheme = monochrome
+	}
+	if css {
+		fmt.Fprint(c.outStream, colorScheme.CSS())
+		return 0
+	}
+
+	base := formatSettings{
+		width:        width,
+		indent:       indent,
+		sortKeys:     sortKeys,
+		layout:       layout,
+		detectIndent: detect,
+		tabWidth:     tabWidth,
+	}
+	var resolver *configResolver
+	if !noConfig {
+		resolver = newConfigResolver()
+	}
+	// invalid is the number of documents that violate the schema.
+	var invalid int32
+	// matched is the number of documents that have matches of --grep.
+	var matched int32
+	formatJSON := func(filename string, jsonStr string) (string, error) {
+		fs := base
+		// The standard input has no configuration unless it is named.
+		if resolver != nil && filename != "" {
+			var err error
+			fs, err = resolver.resolve(filename, base)
+			if err != nil {
+				return "", err
+			}
+			// Flags given explicitly take precedence over configuration files.
+			if set["w"] {
+				fs.width = width
+			}
+			if set["i"] {
+				fs.indent = indent
+			}
+			if set["sort-keys"] {
+				fs.sortKeys = sortKeys
+			}
+			if set["layout"] {
+				fs.layout = layout
+			}
+			if set["detect-indent"] {
+				fs.detectIndent = detect
+			}
+			if set["tab-width"] {
+				fs.tabWidth = tabWidth
+			}
+		}
+		if ungron {
+			var err error
+			jsonStr, err = jpp.Ungron(jsonStr)
+			if err != nil {
+				return "", err
+			}
+		}
+		if unwrapStr {
+			var err error
+			jsonStr, err = unwrap(jsonStr)
+			if err != nil {
+				return "", err
+			}
+		}
+		opts, finalNewline := fs.options(jsonStr)
+		opts.Redaction = redaction
+		if expand {
+			opts.ExpandStrings = expandMax
+		}
+		var res string
+		var err error
+		switch format {
+		case formatHTML:
+			res, err = jpp.FormatHTML(jsonStr, opts, jpp.HTMLOptions{ColorScheme: colorScheme, Standalone: standalone})
+		case formatSVG:
+			res, err = jpp.FormatSVG(jsonStr, opts, jpp.SVGOptions{ColorScheme: colorScheme, Padding: padding})
+		default:
+			opts.Styler = colorScheme
+			var violations []violation
+			if validator != nil {
+				if !gjson.Valid(jsonStr) {
+					return "", errors.New("parse error: Invalid json input")
+				}
+				violations = validator.validate(jsonStr)
+				if len(violations) > 0 {
+					atomic.AddInt32(&invalid, 1)
+				}
+				hl := highlightStyler{base: colorScheme, paths: map[string]bool{}}
+				if useColor {
+					for _, v := range violations {
+						hl.paths[v.path.String()] = true
+					}
+				}
+				opts.Styler = hl
+			}
+			if highlightRe != nil && useColor {
+				opts.Styler = matchStyler{base: opts.Styler, re: highlightRe}
+			}
+			if grepRe != nil {
+				if redaction != nil {
+					// The paths of the matches are relative, so they are redacted beforehand.
+					jsonStr, err = jpp.Format(jsonStr, jpp.Options{Redaction: redaction})
+					if err != nil {
+						return "", err
+					}
+					opts.Redaction = nil
+				} else if !gjson.Valid(jsonStr) {
+					return "", errors.New("parse error: Invalid json input")
+				}
+				results, err := grep(jsonStr, opts, grepRe, context, pathStyle, gutterColor)
+				if err != nil || len(results) == 0 {
+					return "", err
+				}
+				atomic.AddInt32(&matched, 1)
+				// Each match ends with a newline regardless of the input.
+				return strings.Join(results, "\n") + "\n", nil
+			}
+			if gron {
+				res, err = jpp.Gron(jsonStr, opts)
+			} else if shape {
+				res, err = jpp.FormatShape(jsonStr, opts, jpp.ShapeOptions{Counts: counts})
+			} else if paths || lineNums || foldsOut || validator != nil {
+				var mappings []jpp.Mapping
+				res, mappings, err = jpp.FormatWithSourceMap(jsonStr, opts)
+				if err != nil {
+					return "", err
+				}
+				if foldsOut {
+					return foldLines(folds(res, mappings, pathStyle)), nil
+				}
+				// The gutter is added after the layout, so it doesn't count against the width.
+				if len(violations) > 0 {
+					res = annotate(res, mappings, violations, errorColor)
+				}
+				var labels []string
+				lineCount := strings.Count(res, "\n") + 1
+				if lineNums {
+					labels = lineNumbers(lineCount)
+				}
+				if paths {
+					ps := linePaths(res, mappings, pathStyle)
+					if labels == nil {
+						labels = ps
+					} else {
+						for i := range labels {
+							labels[i] += " " + ps[i]
+						}
+					}
+				}
+				if labels != nil {
+					res = addGutter(res, labels, gutterColor)
+				}
+				if len(violations) > 0 {
+					res += "\n" + violationList(violations, errorColor)
+				}
+			} else {
+				res, err = jpp.Format(jsonStr, opts)
+			}
+		}
+		if err != nil {
+			return "", err
 		}
-		output = append(output, input)
+		if finalNewline {
+			res += "\n"
+		}
+		return res, nil
 	}
-	jsonStr := string(output)
 
-	var colorScheme *jpp.ColorScheme
-	if noColor {
-		colorScheme = monochrome
-	} else {
-		colorScheme = defaultCLIScheme
+	if flags.NArg() == 0 {
+		src, err := ioutil.ReadAll(c.inStream)
+		if err != nil {
+			fmt.Fprintln(c.errStream, err.Error())
+			return 1
+		}
+		if !mode.enabled() {
+			res, err := formatJSON(stdinFile, string(src))
+			if err != nil {
+				fmt.Fprintln(c.errStream, err.Error())
+				return 1
+			}
+			fmt.Fprint(c.outStream, res)
+			if invalid > 0 || (grepRe != nil && matched == 0) {
+				return 1
+			}
+			return 0
+		}
+		if mode.write {
+			fmt.Fprintln(c.errStream, "--write cannot be used with standard input")
+			return 1
+		}
+		formatStdin := func(_ string, src string) (string, error) {
+			return formatJSON(stdinFile, src)
+		}
+		unformatted, err := processFile(c.outStream, stdinName, src, formatStdin, mode)
+		if err != nil {
+			fmt.Fprintln(c.errStream, err.Error())
+			return 1
+		}
+		if unformatted && mode.check {
+			return 1
+		}
+		return 0
 	}
 
-	res, err := jpp.Pretty(jsonStr, indent, width, colorScheme)
+	walkOpts := walkOptions{
+		exts:      strings.Split(exts, ","),
+		includes:  includes,
+		excludes:  excludes,
+		gitignore: !noIgnore,
+	}
+	filenames, err := collectFiles(flags.Args(), walkOpts)
 	if err != nil {
 		fmt.Fprintln(c.errStream, err.Error())
 		return 1
 	}
-	fmt.Fprintln(c.outStream, res)
-	return 0
+	status := c.processFiles(filenames, formatJSON, mode, jobs)
+	if invalid > 0 || (grepRe != nil && matched == 0) {
+		status = 1
+	}
+	return status
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"os"
 	"strings"
 	"testing"
 )
@@ -49,3 +50,189 @@ func TestRun_width20(t *testing.T) {
 		t.Errorf("actual=%v, expected: %v", outStream.String(), expected)
 	}
 }
+
+func TestRun_color(t *testing.T) {
+	// The environment of the developer must not affect the result.
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	input := `{"foo": "bar"}`
+	tests := []struct {
+		args    string
+		colored bool
+	}{
+		{"jpp -w 20", false},
+		{"jpp -w 20 --color=auto", false},
+		{"jpp -w 20 --color=always", true},
+		{"jpp -w 20 --color=always --no-color", false},
+		{"jpp -w 20 --color=never", false},
+	}
+	for _, test := range tests {
+		outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+		c := &cli{
+			inStream:  strings.NewReader(input),
+			outStream: outStream,
+			errStream: errStream,
+		}
+		if status := c.run(strings.Split(test.args, " ")); status != 0 {
+			t.Errorf("%v: status=%v, stderr=%v", test.args, status, errStream.String())
+		}
+		colored := strings.Contains(outStream.String(), "\x1b[")
+		if colored != test.colored {
+			t.Errorf("%v: colored=%v, expected: %v", test.args, colored, test.colored)
+		}
+	}
+}
+
+func TestRun_invalidColor(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{
+		inStream:  strings.NewReader(`{}`),
+		outStream: outStream,
+		errStream: errStream,
+	}
+	if status := c.run(strings.Split("jpp --color=sometimes", " ")); status != 1 {
+		t.Errorf("status=%v, expected: 1", status)
+	}
+}
+
+func TestShouldColor(t *testing.T) {
+	defer os.Setenv("NO_COLOR", os.Getenv("NO_COLOR"))
+	defer os.Setenv("FORCE_COLOR", os.Getenv("FORCE_COLOR"))
+
+	tests := []struct {
+		mode       string
+		noColor    string
+		forceColor string
+		isTerminal bool
+		expected   bool
+	}{
+		{"auto", "", "", true, true},
+		{"auto", "", "", false, false},
+		{"auto", "1", "", true, false},
+		{"auto", "", "1", false, true},
+		{"auto", "", "0", false, false},
+		{"auto", "1", "1", false, false},
+		{"always", "1", "", false, true},
+		{"never", "", "1", true, false},
+	}
+	for _, test := range tests {
+		os.Setenv("NO_COLOR", test.noColor)
+		os.Setenv("FORCE_COLOR", test.forceColor)
+		actual, err := shouldColor(test.mode, test.isTerminal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != test.expected {
+			t.Errorf("%+v: actual=%v", test, actual)
+		}
+	}
+}
+
+func TestRun_formatHTML(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{
+		inStream:  strings.NewReader(`[1]`),
+		outStream: outStream,
+		errStream: errStream,
+	}
+	if status := c.run(strings.Split("jpp -w 20 --format html", " ")); status != 0 {
+		t.Fatalf("status=%v, stderr=%v", status, errStream.String())
+	}
+	expected := `<pre class="jpp"><span class="jpp-punctuation">[</span><span class="jpp-number">1</span><span class="jpp-punctuation">]</span></pre>` + "\n"
+	if outStream.String() != expected {
+		t.Errorf("actual=%v, expected: %v", outStream.String(), expected)
+	}
+}
+
+func TestRun_css(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("FORCE_COLOR", "")
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{
+		inStream:  strings.NewReader(``),
+		outStream: outStream,
+		errStream: errStream,
+	}
+	if status := c.run([]string{"jpp", "--css"}); status != 0 {
+		t.Fatalf("status=%v, stderr=%v", status, errStream.String())
+	}
+	// The style sheet is colored even if it isn't written to a terminal.
+	if expected := defaultCLIScheme().CSS(); outStream.String() != expected || !strings.Contains(expected, ".jpp-string {") {
+		t.Errorf("actual=%v, expected: %v", outStream.String(), expected)
+	}
+
+	if status := c.run([]string{"jpp", "--css", "--check"}); status != 1 {
+		t.Errorf("status = %v, want 1 for --css with --check", status)
+	}
+}
+
+func TestRun_expandStrings(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{
+		inStream:  strings.NewReader(`{"a": "{\"b\": \"[1]\"}"}`),
+		outStream: outStream,
+		errStream: errStream,
+	}
+	if status := c.run(strings.Split("jpp --color never --no-config -w 80 --expand-strings --expand-depth 1", " ")); status != 0 {
+		t.Fatalf("status = %v: %v", status, errStream.String())
+	}
+	expected := `{
+  "a": @json {"b": "[1]"}
+}
+`
+	if outStream.String() != expected {
+		t.Errorf("actual=%v, expected: %v", outStream.String(), expected)
+	}
+
+	if status := c.run(strings.Split("jpp --expand-strings --check", " ")); status != 1 {
+		t.Errorf("status = %v, want 1 for --expand-strings with --check", status)
+	}
+}
+
+func TestRun_gron(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{
+		inStream:  strings.NewReader(`{"items": [{"name": "foo"}]}`),
+		outStream: outStream,
+		errStream: errStream,
+	}
+	if status := c.run(strings.Split("jpp --color never --gron", " ")); status != 0 {
+		t.Fatalf("status = %v: %v", status, errStream.String())
+	}
+	gron := "json = {};\njson.items = [];\njson.items[0] = {};\njson.items[0].name = \"foo\";\n"
+	if outStream.String() != gron {
+		t.Errorf("actual=%v, expected: %v", outStream.String(), gron)
+	}
+
+	outStream.Reset()
+	c.inStream = strings.NewReader("json.items[0].name = \"foo\";\n")
+	if status := c.run(strings.Split("jpp -w 80 --ungron", " ")); status != 0 {
+		t.Fatalf("status = %v: %v", status, errStream.String())
+	}
+	expected := `{
+  "items": [
+    {"name": "foo"}
+  ]
+}
+`
+	if outStream.String() != expected {
+		t.Errorf("actual=%v, expected: %v", outStream.String(), expected)
+	}
+}
+
+func TestRun_shape(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{
+		inStream:  strings.NewReader(`{"users": [{"id": 1}, {"id": 2, "email": null}]}`),
+		outStream: outStream,
+		errStream: errStream,
+	}
+	if status := c.run(strings.Split("jpp -w 80 --color never --shape --counts", " ")); status != 0 {
+		t.Fatalf("status = %v: %v", status, errStream.String())
+	}
+	expected := `{"users": [{"id": number, "email"?: null}] ×2}
+`
+	if outStream.String() != expected {
+		t.Errorf("actual=%v, expected: %v", outStream.String(), expected)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tanishiking/jpp"
+)
+
+// configFileNames is the names of the project configuration files,
+// in the order of precedence in a directory.
+var configFileNames = []string{".jpp.json", ".jpp.toml"}
+
+// settings is the formatter settings in a configuration file.
+// Nil fields are not specified.
+type settings struct {
+	Width    *int    `json:"width"`
+	Indent   *string `json:"indent"`
+	SortKeys *bool   `json:"sortKeys"`
+	Layout   *string `json:"layout"`
+	// DetectIndent keeps the indentation and the final newline of files.
+	DetectIndent *bool `json:"detectIndent"`
+	TabWidth     *int  `json:"tabWidth"`
+}
+
+// override is the settings for the files matched by the glob patterns.
+type override struct {
+	Files []string `json:"files"`
+	settings
+}
+
+// config is a project configuration file such as:
+//
+//	{
+//	  "width": 100,
+//	  "indent": "  ",
+//	  "sortKeys": true,
+//	  "layout": "compact",
+//	  "overrides": [{"files": ["fixtures/**/*.json"], "layout": "expanded"}]
+//	}
+type config struct {
+	settings
+	Overrides []override `json:"overrides"`
+
+	// dir is the directory of the configuration file.
+	dir string
+}
+
+// loadConfig reads the configuration file in dir.
+// It returns nil if there are no configuration files.
+func loadConfig(dir string) (*config, error) {
+	for _, name := range configFileNames {
+		filename := filepath.Join(dir, name)
+		data, err := ioutil.ReadFile(filename)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if filepath.Ext(name) == ".toml" {
+			m, err := parseTOML(string(data))
+			if err != nil {
+				return nil, fmt.Errorf("%v: %v", filename, err)
+			}
+			if data, err = json.Marshal(m); err != nil {
+				return nil, fmt.Errorf("%v: %v", filename, err)
+			}
+		}
+		c := &config{dir: dir}
+		if err := json.Unmarshal(data, c); err != nil {
+			return nil, fmt.Errorf("%v: %v", filename, err)
+		}
+		return c, nil
+	}
+	return nil, nil
+}
+
+// formatSettings is the resolved settings to format a file.
+type formatSettings struct {
+	width        int
+	indent       string
+	sortKeys     bool
+	layout       jpp.Layout
+	detectIndent bool
+	tabWidth     int
+}
+
+func (fs *formatSettings) apply(s settings) error {
+	if s.Width != nil {
+		fs.width = *s.Width
+	}
+	if s.Indent != nil {
+		fs.indent = *s.Indent
+	}
+	if s.SortKeys != nil {
+		fs.sortKeys = *s.SortKeys
+	}
+	if s.Layout != nil {
+		layout, err := jpp.ParseLayout(*s.Layout)
+		if err != nil {
+			return err
+		}
+		fs.layout = layout
+	}
+	if s.DetectIndent != nil {
+		fs.detectIndent = *s.DetectIndent
+	}
+	if s.TabWidth != nil {
+		fs.tabWidth = *s.TabWidth
+	}
+	return nil
+}
+
+// options returns jpp.Options to format src, and whether the formatted
+// content ends with a newline. The indentation of src is detected if
+// detectIndent is set.
+func (fs formatSettings) options(src string) (jpp.Options, bool) {
+	opts := jpp.Options{
+		Indent:   fs.indent,
+		Width:    fs.width,
+		SortKeys: fs.sortKeys,
+		Layout:   fs.layout,
+		TabWidth: fs.tabWidth,
+	}
+	finalNewline := true
+	if fs.detectIndent {
+		detected := jpp.Detect(src)
+		if detected.Indent != "" {
+			opts.Indent = detected.Indent
+		}
+		finalNewline = detected.FinalNewline
+	}
+	return opts, finalNewline
+}
+
+// applyEditorconfig applies indent_style, indent_size, tab_width and
+// max_line_length.
+func (fs *formatSettings) applyEditorconfig(props map[string]string) {
+	size := props["indent_size"]
+	if size == "tab" {
+		size = props["tab_width"]
+	}
+	// tab_width defaults to indent_size.
+	tabWidth := props["tab_width"]
+	if tabWidth == "" {
+		tabWidth = size
+	}
+	if n, err := strconv.Atoi(tabWidth); err == nil && n > 0 {
+		fs.tabWidth = n
+	}
+	switch props["indent_style"] {
+	case "tab":
+		fs.indent = "\t"
+	case "space":
+		if n, err := strconv.Atoi(size); err == nil && n >= 0 {
+			fs.indent = strings.Repeat(" ", n)
+		}
+	default:
+		if n, err := strconv.Atoi(size); err == nil && n >= 0 && !strings.Contains(fs.indent, "\t") {
+			fs.indent = strings.Repeat(" ", n)
+		}
+	}
+	if n, err := strconv.Atoi(props["max_line_length"]); err == nil && n > 0 {
+		fs.width = n
+	}
+}
+
+// configResolver finds the settings for files.
+// The configuration files are cached, and it is safe for concurrent use.
+type configResolver struct {
+	mu            sync.Mutex
+	configs       map[string]*config
+	editorconfigs map[string]*editorconfig
+}
+
+func newConfigResolver() *configResolver {
+	return &configResolver{
+		configs:       map[string]*config{},
+		editorconfigs: map[string]*editorconfig{},
+	}
+}
+
+// resolve returns the settings for the file, applying .editorconfig files
+// and then the nearest .jpp.json or .jpp.toml found by walking up from
+// the file onto base.
+func (r *configResolver) resolve(filename string, base formatSettings) (formatSettings, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return base, err
+	}
+	fs := base
+
+	var ecs []*editorconfig
+	var nearest *config
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		ec, err := r.editorconfig(dir)
+		if err != nil {
+			return base, err
+		}
+		if ec != nil && (len(ecs) == 0 || !ecs[len(ecs)-1].root) {
+			ecs = append(ecs, ec)
+		}
+		if nearest == nil {
+			if nearest, err = r.config(dir); err != nil {
+				return base, err
+			}
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	// Apply .editorconfig files from the outermost below the root one.
+	for i := len(ecs) - 1; i >= 0; i-- {
+		fs.applyEditorconfig(ecs[i].properties(abs))
+	}
+
+	if nearest == nil {
+		return fs, nil
+	}
+	if err := fs.apply(nearest.settings); err != nil {
+		return base, err
+	}
+	rel, err := filepath.Rel(nearest.dir, abs)
+	if err != nil {
+		return base, err
+	}
+	rel = filepath.ToSlash(rel)
+	for _, o := range nearest.Overrides {
+		if matchAny(o.Files, rel) {
+			if err := fs.apply(o.settings); err != nil {
+				return base, err
+			}
+		}
+	}
+	return fs, nil
+}
+
+func (r *configResolver) config(dir string) (*config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.configs[dir]; ok {
+		return c, nil
+	}
+	c, err := loadConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+	r.configs[dir] = c
+	return c, nil
+}
+
+func (r *configResolver) editorconfig(dir string) (*editorconfig, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ec, ok := r.editorconfigs[dir]; ok {
+		return ec, nil
+	}
+	ec, err := loadEditorconfig(dir)
+	if err != nil {
+		return nil, err
+	}
+	r.editorconfigs[dir] = ec
+	return ec, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tanishiking/jpp"
+)
+
+func TestConfigResolver(t *testing.T) {
+	dir := setupTree(t, map[string]string{
+		".editorconfig": `root = true
+
+[*]
+indent_style = tab
+max_line_length = 120
+
+[*.json]
+indent_style = space
+indent_size = 4
+`,
+		"project/.jpp.json": `{
+  "width": 60,
+  "sortKeys": true,
+  "overrides": [
+    {"files": ["fixtures/**/*.json"], "layout": "expanded"},
+    {"files": ["*.min.json"], "width": 200}
+  ]
+}`,
+		"project/fixtures/a/b.json":  "{}",
+		"project/c.min.json":         "{}",
+		"project/sub/.jpp.toml":      "indent = \"\\t\"\n",
+		"project/sub/d.json":         "{}",
+		"other/e.json":               "{}",
+		"other/f.txt":                "",
+		"other/nested/.editorconfig": "[*.json]\nindent_size = 3\n",
+		"other/nested/g.json":        "{}",
+	})
+	defer os.RemoveAll(dir)
+
+	base := formatSettings{width: 80, indent: "  "}
+	tests := []struct {
+		file     string
+		expected formatSettings
+	}{
+		{"project/fixtures/a/b.json", formatSettings{width: 60, indent: "    ", sortKeys: true, layout: jpp.LayoutExpanded, tabWidth: 4}},
+		{"project/c.min.json", formatSettings{width: 200, indent: "    ", sortKeys: true, tabWidth: 4}},
+		// The nearest configuration file wins.
+		{"project/sub/d.json", formatSettings{width: 120, indent: "\t", tabWidth: 4}},
+		{"other/e.json", formatSettings{width: 120, indent: "    ", tabWidth: 4}},
+		{"other/f.txt", formatSettings{width: 120, indent: "\t"}},
+		{"other/nested/g.json", formatSettings{width: 120, indent: "   ", tabWidth: 3}},
+	}
+	r := newConfigResolver()
+	for _, test := range tests {
+		actual, err := r.resolve(filepath.Join(dir, test.file), base)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != test.expected {
+			t.Errorf("%v: expected: %+v, actual: %+v", test.file, test.expected, actual)
+		}
+	}
+}
+
+func TestRun_config(t *testing.T) {
+	dir := setupTree(t, map[string]string{
+		".jpp.json": `{"width": 20, "indent": "    ", "sortKeys": true}`,
+		"a.json":    `{"b": [1, 2, 3, 4, 5, 6, 7, 8, 9, 10], "a": 1}`,
+	})
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "a.json")
+
+	_, stdout, stderr := runWithFiles(filename)
+	expected := `{
+    "a": 1,
+    "b": [
+        1, 2, 3, 4,
+        5, 6, 7, 8,
+        9, 10
+    ]
+}
+`
+	if stdout != expected {
+		t.Errorf("expected: %v, actual: %v, stderr: %v", expected, stdout, stderr)
+	}
+
+	// Flags given explicitly take precedence.
+	_, stdout, _ = runWithFiles("-w", "100", "-i", "  ", filename)
+	expected = `{
+  "a": 1,
+  "b": [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]
+}
+`
+	if stdout != expected {
+		t.Errorf("expected: %v, actual: %v", expected, stdout)
+	}
+
+	_, stdout, _ = runWithFiles("-w", "100", "--no-config", filename)
+	expected = `{
+  "b": [1, 2, 3, 4, 5, 6, 7, 8, 9, 10],
+  "a": 1
+}
+`
+	if stdout != expected {
+		t.Errorf("expected: %v, actual: %v", expected, stdout)
+	}
+}
+
+func TestRun_configStdin(t *testing.T) {
+	dir := setupTree(t, map[string]string{
+		".editorconfig": "[*]\nindent_size = 4\n",
+	})
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	input := `{"a": [1, 2, 3, 4, 5, 6]}`
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{nil, "{\n  \"a\": [1, 2, 3, 4, 5, 6]\n}\n"},
+		{[]string{"--stdin-filename", "a.json"}, "{\n    \"a\": [\n        1, 2, 3, 4,\n        5, 6\n    ]\n}\n"},
+	}
+	for _, test := range tests {
+		outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+		c := &cli{inStream: strings.NewReader(input), outStream: outStream, errStream: errStream}
+		c.run(append([]string{"jpp", "-w", "20"}, test.args...))
+		if outStream.String() != test.expected {
+			t.Errorf("%v: got\n%v\nwant\n%v%v", test.args, outStream.String(), test.expected, errStream.String())
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around changes in a hunk.
+const diffContext = 3
+
+type editKind int
+
+const (
+	editEqual editKind = iota
+	editDelete
+	editInsert
+)
+
+type edit struct {
+	kind editKind
+	// a and b are the indices of the line in the old and new text.
+	a, b int
+}
+
+// unifiedDiff returns the unified diff from oldText to newText,
+// or an empty string if they are identical.
+func unifiedDiff(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+	a := splitLines(oldText)
+	b := splitLines(newText)
+	edits := diffLines(a, b)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- %v\n+++ %v\n", oldName, newName)
+	for start := 0; start < len(edits); {
+		// Find the next change and gather the changes close to each other
+		// into a hunk.
+		for start < len(edits) && edits[start].kind == editEqual {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+		end := start
+		for i := start; i < len(edits); i++ {
+			if edits[i].kind != editEqual {
+				end = i + 1
+			} else if i-end >= diffContext*2 {
+				break
+			}
+		}
+		hunkStart := start - diffContext
+		if hunkStart < 0 {
+			hunkStart = 0
+		}
+		hunkEnd := end + diffContext
+		if hunkEnd > len(edits) {
+			hunkEnd = len(edits)
+		}
+		writeHunk(&buf, a, b, edits[hunkStart:hunkEnd])
+		start = hunkEnd
+	}
+	return buf.String()
+}
+
+func writeHunk(buf *bytes.Buffer, a, b []string, edits []edit) {
+	aStart, bStart := edits[0].a, edits[0].b
+	aLen, bLen := 0, 0
+	for _, e := range edits {
+		if e.kind != editInsert {
+			aLen++
+		}
+		if e.kind != editDelete {
+			bLen++
+		}
+	}
+	fmt.Fprintf(buf, "@@ -%v +%v @@\n", hunkRange(aStart, aLen), hunkRange(bStart, bLen))
+	for _, e := range edits {
+		switch e.kind {
+		case editEqual:
+			writeDiffLine(buf, " ", a[e.a])
+		case editDelete:
+			writeDiffLine(buf, "-", a[e.a])
+		case editInsert:
+			writeDiffLine(buf, "+", b[e.b])
+		}
+	}
+}
+
+// hunkRange formats the range of lines as "start,length",
+// where start is 1-based unless the range is empty.
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%v,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%v", start+1)
+	}
+	return fmt.Sprintf("%v,%v", start+1, length)
+}
+
+func writeDiffLine(buf *bytes.Buffer, prefix, line string) {
+	buf.WriteString(prefix)
+	buf.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		buf.WriteString("\n\\ No newline at end of file\n")
+	}
+}
+
+// splitLines splits text into lines keeping their line endings.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes the shortest edit script from a to b with the linear
+// space variant of Myers' O(ND) algorithm, which splits the problem at the
+// middle snake recursively.
+func diffLines(a, b []string) []edit {
+	var edits []edit
+	diffRange(a, b, 0, len(a), 0, len(b), &edits)
+
+	// Put the deletions before the insertions in each run of changes,
+	// which the splits may have interleaved.
+	for start := 0; start < len(edits); {
+		if edits[start].kind == editEqual {
+			start++
+			continue
+		}
+		end := start
+		var dels, inss []edit
+		for ; end < len(edits) && edits[end].kind != editEqual; end++ {
+			if edits[end].kind == editDelete {
+				dels = append(dels, edits[end])
+			} else {
+				inss = append(inss, edits[end])
+			}
+		}
+		x, y := edits[start].a, edits[start].b
+		for i, e := range dels {
+			edits[start+i] = edit{kind: editDelete, a: e.a, b: y}
+		}
+		for i, e := range inss {
+			edits[start+len(dels)+i] = edit{kind: editInsert, a: x + len(dels), b: e.b}
+		}
+		start = end
+	}
+	return edits
+}
+
+// diffRange appends the edits from a[aLo:aHi] to b[bLo:bHi] to edits.
+func diffRange(a, b []string, aLo, aHi, bLo, bHi int, edits *[]edit) {
+	for aLo < aHi && bLo < bHi && a[aLo] == b[bLo] {
+		*edits = append(*edits, edit{kind: editEqual, a: aLo, b: bLo})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && a[aHi-1-suffix] == b[bHi-1-suffix] {
+		suffix++
+	}
+	aHi -= suffix
+	bHi -= suffix
+
+	switch {
+	case aLo == aHi:
+		for y := bLo; y < bHi; y++ {
+			*edits = append(*edits, edit{kind: editInsert, a: aLo, b: y})
+		}
+	case bLo == bHi:
+		for x := aLo; x < aHi; x++ {
+			*edits = append(*edits, edit{kind: editDelete, a: x, b: bLo})
+		}
+	default:
+		// Both ends differ, so the script has at least two edits and
+		// both halves around the middle snake are smaller.
+		x, y, u, v := middleSnake(a[aLo:aHi], b[bLo:bHi])
+		diffRange(a, b, aLo, aLo+x, bLo, bLo+y, edits)
+		for i := 0; i < u-x; i++ {
+			*edits = append(*edits, edit{kind: editEqual, a: aLo + x + i, b: bLo + y + i})
+		}
+		diffRange(a, b, aLo+u, aHi, bLo+v, bHi, edits)
+	}
+
+	for i := 0; i < suffix; i++ {
+		*edits = append(*edits, edit{kind: editEqual, a: aHi + i, b: bHi + i})
+	}
+}
+
+// middleSnake returns the start (x, y) and the end (u, v) of the middle
+// snake of the shortest edit script from a to b, found by searching from
+// both ends at the same time.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	// forward[k] is the furthest x on the diagonal k = x - y from (0, 0), and
+	// backward[k] is the furthest x on the diagonal k of the reversed texts.
+	forward := make([]int, 2*maxD+3)
+	backward := make([]int, 2*maxD+3)
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			// The diagonal k is the diagonal delta-k of the reversed texts.
+			if rk := delta - k; odd && rk >= -(d-1) && rk <= d-1 && x+backward[offset+rk] >= n {
+				return startX, startY, x, y
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			if fk := delta - k; !odd && fk >= -d && fk <= d && x+forward[offset+fk] >= n {
+				return n - x, m - y, n - startX, m - startY
+			}
+		}
+	}
+	// Unreachable since the script has at most n+m edits.
+	return 0, 0, 0, 0
+}
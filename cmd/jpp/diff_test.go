@@ -0,0 +1,143 @@
+package main
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	newText := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+	expected := `--- old
++++ new
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -11,3 +11,4 @@
+ k
+ l
+ m
++n
+`
+	if actual := unifiedDiff("old", "new", oldText, newText); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestUnifiedDiff_NoNewlineAtEOF(t *testing.T) {
+	expected := `--- old
++++ new
+@@ -1 +1,3 @@
+-{"a":1}
+\ No newline at end of file
++{
++  "a": 1
++}
+`
+	if actual := unifiedDiff("old", "new", `{"a":1}`, "{\n  \"a\": 1\n}\n"); actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestUnifiedDiff_Identical(t *testing.T) {
+	if actual := unifiedDiff("old", "new", "a\n", "a\n"); actual != "" {
+		t.Errorf("expected no diff, actual: %v", actual)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	a := strings.Split("a b c a b b a", " ")
+	b := strings.Split("c b a b a c", " ")
+	edits := diffLines(a, b)
+	// Applying the edits to a must result in b.
+	var applied []string
+	changes := 0
+	for _, e := range edits {
+		switch e.kind {
+		case editEqual:
+			applied = append(applied, a[e.a])
+		case editInsert:
+			applied = append(applied, b[e.b])
+			changes++
+		case editDelete:
+			changes++
+		}
+	}
+	if strings.Join(applied, " ") != strings.Join(b, " ") {
+		t.Errorf("actual: %v", applied)
+	}
+	if changes != 5 {
+		t.Errorf("expected the shortest edit script of 5 changes, actual: %v", changes)
+	}
+}
+
+func TestDiffLines_Random(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	random := func() []string {
+		lines := make([]string, rnd.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + rnd.Intn(4)))
+		}
+		return lines
+	}
+	for n := 0; n < 500; n++ {
+		a, b := random(), random()
+		var applied []string
+		changes := 0
+		for _, e := range diffLines(a, b) {
+			switch e.kind {
+			case editEqual:
+				applied = append(applied, a[e.a])
+			case editInsert:
+				applied = append(applied, b[e.b])
+				changes++
+			case editDelete:
+				changes++
+			}
+		}
+		if strings.Join(applied, "") != strings.Join(b, "") {
+			t.Fatalf("%v to %v: applied %v", a, b, applied)
+		}
+		if expected := len(a) + len(b) - 2*lcsLength(a, b); changes != expected {
+			t.Fatalf("%v to %v: %v changes, expected %v", a, b, changes, expected)
+		}
+	}
+}
+
+func lcsLength(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else if dp[i+1][j] > dp[i][j+1] {
+				dp[i][j] = dp[i+1][j]
+			} else {
+				dp[i][j] = dp[i][j+1]
+			}
+		}
+	}
+	return dp[0][0]
+}
+
+func TestDiffLines_Large(t *testing.T) {
+	// Every line differs, which is the worst case for the memory.
+	a := make([]string, 5000)
+	b := make([]string, 5000)
+	for i := range a {
+		a[i] = "a" + strconv.Itoa(i)
+		b[i] = "b" + strconv.Itoa(i)
+	}
+	if edits := diffLines(a, b); len(edits) != len(a)+len(b) {
+		t.Errorf("got %v edits", len(edits))
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// editorconfig is an .editorconfig file.
+// See: https://editorconfig.org
+type editorconfig struct {
+	dir      string
+	root     bool
+	sections []editorconfigSection
+}
+
+type editorconfigSection struct {
+	glob       *regexp.Regexp
+	properties map[string]string
+}
+
+// loadEditorconfig reads the .editorconfig file in dir.
+// It returns nil if there is no .editorconfig file.
+func loadEditorconfig(dir string) (*editorconfig, error) {
+	f, err := os.Open(filepath.Join(dir, ".editorconfig"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ec := &editorconfig{dir: dir}
+	var section *editorconfigSection
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			glob, err := editorconfigGlob(line[1 : len(line)-1])
+			if err != nil {
+				// Skip the sections we can't understand.
+				section = nil
+				continue
+			}
+			ec.sections = append(ec.sections, editorconfigSection{glob: glob, properties: map[string]string{}})
+			section = &ec.sections[len(ec.sections)-1]
+			continue
+		}
+		sep := strings.IndexAny(line, "=:")
+		if sep < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:sep]))
+		value := strings.ToLower(strings.TrimSpace(line[sep+1:]))
+		if section == nil {
+			if key == "root" {
+				ec.root = value == "true"
+			}
+			continue
+		}
+		section.properties[key] = value
+	}
+	return ec, scanner.Err()
+}
+
+// properties returns the properties that apply to the absolute path
+// filename. Later sections take precedence.
+func (ec *editorconfig) properties(filename string) map[string]string {
+	rel, err := filepath.Rel(ec.dir, filename)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil
+	}
+	rel = filepath.ToSlash(rel)
+	props := map[string]string{}
+	for _, section := range ec.sections {
+		if section.glob.MatchString(rel) {
+			for k, v := range section.properties {
+				props[k] = v
+			}
+		}
+	}
+	return props
+}
+
+// editorconfigGlob converts the glob of a section to a regexp matched
+// against the slash-separated path relative to the .editorconfig file.
+// A glob without slashes matches the file name at any level.
+func editorconfigGlob(glob string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	if strings.Contains(glob, "/") {
+		glob = strings.TrimPrefix(glob, "/")
+	} else {
+		b.WriteString("(?:.*/)?")
+	}
+	braces := 0
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		case '{':
+			end := strings.IndexByte(glob[i:], '}')
+			if end >= 0 {
+				if r, ok := numericRange(glob[i+1 : i+end]); ok {
+					b.WriteString(r)
+					i += end
+					continue
+				}
+			}
+			braces++
+			b.WriteString("(?:")
+		case '}':
+			if braces > 0 {
+				braces--
+				b.WriteString(")")
+			} else {
+				b.WriteString(`\}`)
+			}
+		case ',':
+			if braces > 0 {
+				b.WriteString("|")
+			} else {
+				b.WriteString(",")
+			}
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(glob[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return regexp.Compile("^" + b.String() + "$")
+}
+
+// numericRange converts "{n1..n2}" to a regexp matching the integers
+// between n1 and n2.
+func numericRange(body string) (string, bool) {
+	parts := strings.Split(body, "..")
+	if len(parts) != 2 {
+		return "", false
+	}
+	from, err1 := strconv.Atoi(parts[0])
+	to, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || from > to || to-from > 1000 {
+		return "", false
+	}
+	var alts []string
+	for n := from; n <= to; n++ {
+		alts = append(alts, strconv.Itoa(n))
+	}
+	return "(?:" + strings.Join(alts, "|") + ")", true
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestEditorconfigGlob(t *testing.T) {
+	tests := []struct {
+		glob     string
+		path     string
+		expected bool
+	}{
+		{"*", "a.json", true},
+		{"*", "dir/a.json", true},
+		{"*.json", "dir/a.json", true},
+		{"*.{json,jsonc}", "a.jsonc", true},
+		{"*.{json,jsonc}", "a.yaml", false},
+		{"dir/*.json", "dir/a.json", true},
+		{"dir/*.json", "dir/sub/a.json", false},
+		{"/dir/**.json", "dir/sub/a.json", true},
+		{"file{1..3}.json", "file2.json", true},
+		{"file{1..3}.json", "file4.json", false},
+		{"[ab].json", "a.json", true},
+		{"[!ab].json", "a.json", false},
+		{"a?.json", "ab.json", true},
+	}
+	for _, test := range tests {
+		re, err := editorconfigGlob(test.glob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := re.MatchString(test.path); actual != test.expected {
+			t.Errorf("%q matches %q = %v, expected: %v", test.glob, test.path, actual, test.expected)
+		}
+	}
+}
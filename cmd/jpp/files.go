@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// stdinName is the name of the standard input in --check and --diff output.
+const stdinName = "<standard input>"
+
+// fileMode specifies how jpp reports the formatting of files,
+// following gofmt's -w, -l and -d.
+type fileMode struct {
+	// write rewrites the files with the formatted content.
+	write bool
+	// check lists the files whose formatting differs from jpp's.
+	check bool
+	// diff shows the unified diff between the files and the formatted content.
+	diff bool
+}
+
+func (m fileMode) enabled() bool {
+	return m.write || m.check || m.diff
+}
+
+// fileResult is the result of processing a file.
+type fileResult struct {
+	output      bytes.Buffer
+	unformatted bool
+	err         error
+}
+
+// processFiles formats the files according to the mode with the given
+// number of workers, and reports the results in the order of filenames.
+// It returns the exit status, which is 1 if some files couldn't be
+// processed, or if --check is given and some files are not formatted.
+func (c *cli) processFiles(filenames []string, format func(filename string, src string) (string, error), m fileMode, jobs int) int {
+	if jobs < 1 {
+		jobs = 1
+	}
+	results := make([]fileResult, len(filenames))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				r := &results[i]
+				src, err := ioutil.ReadFile(filenames[i])
+				if err != nil {
+					r.err = err
+					continue
+				}
+				r.unformatted, r.err = processFile(&r.output, filenames[i], src, format, m)
+			}
+		}()
+	}
+	for i := range filenames {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	status := 0
+	for i := range results {
+		r := &results[i]
+		c.outStream.Write(r.output.Bytes())
+		if r.err != nil {
+			fmt.Fprintln(c.errStream, r.err.Error())
+			status = 1
+		}
+		if r.unformatted && m.check {
+			status = 1
+		}
+	}
+	return status
+}
+
+// processFile formats src, the content of filename, and writes the result
+// to w according to the mode. format returns the whole formatted content
+// including the final newline. It returns true if src was not formatted.
+func processFile(w io.Writer, filename string, src []byte, format func(filename string, src string) (string, error), m fileMode) (bool, error) {
+	res, err := format(filename, string(src))
+	if err != nil {
+		return false, fmt.Errorf("%v: %v", filename, err)
+	}
+	if !m.enabled() {
+		fmt.Fprint(w, res)
+		return false, nil
+	}
+	formatted := []byte(res)
+	if bytes.Equal(src, formatted) {
+		return false, nil
+	}
+
+	if m.check {
+		fmt.Fprintln(w, filename)
+	}
+	if m.write {
+		if err := writeFileAtomically(filename, formatted); err != nil {
+			return true, err
+		}
+	}
+	if m.diff {
+		fmt.Fprint(w, unifiedDiff(filename+".orig", filename, string(src), string(formatted)))
+	}
+	return true, nil
+}
+
+// writeFileAtomically replaces the file with data by writing it to
+// a temporary file in the same directory and renaming it.
+// The permission of the original file is preserved.
+func writeFileAtomically(filename string, data []byte) (err error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := ioutil.TempFile(dir, "."+base+".jpp-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	unformattedJSON = `{"a":1,"b":[1,2]}`
+	formattedJSON   = "{\n  \"a\": 1,\n  \"b\": [1, 2]\n}\n"
+)
+
+func setupFiles(t *testing.T) (dir string, unformatted string, formatted string) {
+	dir, err := ioutil.TempDir("", "jpp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unformatted = filepath.Join(dir, "unformatted.json")
+	formatted = filepath.Join(dir, "formatted.json")
+	if err := ioutil.WriteFile(unformatted, []byte(unformattedJSON), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(formatted, []byte(formattedJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir, unformatted, formatted
+}
+
+func runWithFiles(args ...string) (status int, stdout string, stderr string) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{
+		inStream:  strings.NewReader(""),
+		outStream: outStream,
+		errStream: errStream,
+	}
+	status = c.run(append([]string{"jpp"}, args...))
+	return status, outStream.String(), errStream.String()
+}
+
+func TestRun_files(t *testing.T) {
+	dir, unformatted, formatted := setupFiles(t)
+	defer os.RemoveAll(dir)
+
+	status, stdout, stderr := runWithFiles("-w", "80", unformatted, formatted)
+	if status != 0 {
+		t.Fatalf("status=%v, stderr=%v", status, stderr)
+	}
+	if stdout != formattedJSON+formattedJSON {
+		t.Errorf("actual: %v", stdout)
+	}
+}
+
+func TestRun_check(t *testing.T) {
+	dir, unformatted, formatted := setupFiles(t)
+	defer os.RemoveAll(dir)
+
+	status, stdout, _ := runWithFiles("--check", unformatted, formatted)
+	if status != 1 {
+		t.Errorf("status=%v, expected: 1", status)
+	}
+	if stdout != unformatted+"\n" {
+		t.Errorf("actual: %v", stdout)
+	}
+
+	status, stdout, _ = runWithFiles("--check", formatted)
+	if status != 0 || stdout != "" {
+		t.Errorf("status=%v, stdout=%v", status, stdout)
+	}
+}
+
+func TestRun_checkNumbers(t *testing.T) {
+	dir, _, _ := setupFiles(t)
+	defer os.RemoveAll(dir)
+	numbers := filepath.Join(dir, "numbers.json")
+	src := "[12345678901234567891, 1.50, 1e400]\n"
+	if err := ioutil.WriteFile(numbers, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	status, stdout, stderr := runWithFiles("--check", "--no-config", numbers)
+	if status != 0 || stdout != "" {
+		t.Errorf("status=%v, stdout=%v, stderr=%v", status, stdout, stderr)
+	}
+}
+
+func TestRun_write(t *testing.T) {
+	dir, unformatted, formatted := setupFiles(t)
+	defer os.RemoveAll(dir)
+
+	status, stdout, stderr := runWithFiles("--write", unformatted, formatted)
+	if status != 0 || stdout != "" {
+		t.Fatalf("status=%v, stdout=%v, stderr=%v", status, stdout, stderr)
+	}
+	actual, _ := ioutil.ReadFile(unformatted)
+	if string(actual) != formattedJSON {
+		t.Errorf("actual: %v", string(actual))
+	}
+	info, _ := os.Stat(unformatted)
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("expected the permission to be preserved, actual: %v", info.Mode())
+	}
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 2 {
+		t.Errorf("expected no temporary files to be left, actual: %v", files)
+	}
+}
+
+func TestRun_diff(t *testing.T) {
+	dir, unformatted, formatted := setupFiles(t)
+	defer os.RemoveAll(dir)
+
+	status, stdout, _ := runWithFiles("--diff", unformatted, formatted)
+	if status != 0 {
+		t.Errorf("status=%v, expected: 0", status)
+	}
+	expected := "--- " + unformatted + ".orig\n+++ " + unformatted + "\n" + `@@ -1 +1,4 @@
+-{"a":1,"b":[1,2]}
+\ No newline at end of file
++{
++  "a": 1,
++  "b": [1, 2]
++}
+`
+	if stdout != expected {
+		t.Errorf("expected: %v, actual: %v", expected, stdout)
+	}
+	actual, _ := ioutil.ReadFile(unformatted)
+	if string(actual) != unformattedJSON {
+		t.Errorf("expected the file not to be modified, actual: %v", string(actual))
+	}
+}
+
+func TestRun_checkStdin(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{
+		inStream:  strings.NewReader(unformattedJSON),
+		outStream: outStream,
+		errStream: errStream,
+	}
+	if status := c.run([]string{"jpp", "--check"}); status != 1 {
+		t.Errorf("status=%v, expected: 1", status)
+	}
+	if outStream.String() != stdinName+"\n" {
+		t.Errorf("actual: %v", outStream.String())
+	}
+}
+
+func TestRun_directory(t *testing.T) {
+	files := map[string]string{}
+	var expected string
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		files[name+"/x.json"] = `{"name":"` + name + `"}`
+		expected += "{\"name\": \"" + name + "\"}\n"
+	}
+	dir := setupTree(t, files)
+	defer os.RemoveAll(dir)
+
+	for _, jobs := range []string{"1", "4"} {
+		status, stdout, stderr := runWithFiles("-w", "80", "--jobs", jobs, dir+"/...")
+		if status != 0 {
+			t.Fatalf("status=%v, stderr=%v", status, stderr)
+		}
+		if stdout != expected {
+			t.Errorf("jobs %v: expected: %v, actual: %v", jobs, expected, stdout)
+		}
+	}
+}
+
+func TestRun_detectIndent(t *testing.T) {
+	dir := setupTree(t, map[string]string{
+		"tabs.json":    "{\n\t\"a\": {\"b\": [1,\n\t\t2]}}",
+		"spaces.json":  "{\n    \"a\": {\n        \"b\": [1, 2]\n    }\n}\n",
+		"compact.json": `{"a": {"b": [1, 2]}}`,
+	})
+	defer os.RemoveAll(dir)
+
+	status, stdout, stderr := runWithFiles("--detect-indent", "--diff", "--no-config", dir)
+	if status != 0 {
+		t.Fatalf("status=%v, stderr=%v", status, stderr)
+	}
+	compact := filepath.Join(dir, "compact.json")
+	tabs := filepath.Join(dir, "tabs.json")
+	expected := "--- " + compact + ".orig\n+++ " + compact + "\n" + `@@ -1 +1,5 @@
+-{"a": {"b": [1, 2]}}
+\ No newline at end of file
++{
++  "a": {
++    "b": [1, 2]
++  }
++}
+\ No newline at end of file
+` + "--- " + tabs + ".orig\n+++ " + tabs + "\n" + `@@ -1,3 +1,5 @@
+ {
+-	"a": {"b": [1,
+-		2]}}
+\ No newline at end of file
++	"a": {
++		"b": [1, 2]
++	}
++}
+\ No newline at end of file
+`
+	if stdout != expected {
+		t.Errorf("expected: %v, actual: %v", expected, stdout)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/tanishiking/jpp"
+)
+
+// fold is the range of lines of a container in the formatted text.
+// Lines are numbered from 1.
+type fold struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Kind  string `json:"kind"`
+	Path  string `json:"path"`
+}
+
+// folds returns the ranges of the containers in res, ordered by their starts.
+// The paths are formatted in style.
+func folds(res string, mappings []jpp.Mapping, style string) []fold {
+	var fs []fold
+	// open holds the indexes of the folds whose containers are not closed yet.
+	var open []int
+	line, pos := 1, 0
+	for _, m := range mappings {
+		for ; pos < m.Output.Start; pos++ {
+			if res[pos] == '\n' {
+				line++
+			}
+		}
+		if m.Kind != jpp.PunctuationToken {
+			continue
+		}
+		// The tokens in expanded strings map to the whole strings in the
+		// input, so the brackets are found by their text.
+		switch m.Text {
+		case "[", "{":
+			kind := "array"
+			if m.Text == "{" {
+				kind = "object"
+			}
+			open = append(open, len(fs))
+			fs = append(fs, fold{Start: line, Kind: kind, Path: formatPath(m.Path, style)})
+		case "]", "}":
+			fs[open[len(open)-1]].End = line
+			open = open[:len(open)-1]
+		}
+	}
+	return fs
+}
+
+// foldLines encodes the folds as JSON Lines.
+func foldLines(fs []fold) string {
+	var b strings.Builder
+	for _, f := range fs {
+		line, _ := json.Marshal(f)
+		b.Write(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tanishiking/jpp"
+)
+
+func TestFolds(t *testing.T) {
+	src := `{"a": [1, 2, 3, 4, 5, 6, 7, 8], "b": {"c": []}}`
+	res, mappings, err := jpp.FormatWithSourceMap(src, jpp.Options{Indent: "  ", Width: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The array is broken into lines by the fill layout.
+	expected := []fold{
+		{Start: 1, End: 9, Kind: "object", Path: "$"},
+		{Start: 2, End: 5, Kind: "array", Path: "$.a"},
+		{Start: 6, End: 8, Kind: "object", Path: "$.b"},
+		{Start: 7, End: 7, Kind: "array", Path: "$.b.c"},
+	}
+	actual := folds(res, mappings, pathStyleDotted)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got %+v, want %+v for\n%v", actual, expected, res)
+	}
+}
+
+func TestRun_folds(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{
+		inStream:  strings.NewReader(`{"a": [1], "b": {"c": null}}`),
+		outStream: outStream,
+		errStream: errStream,
+	}
+	if status := c.run(strings.Split("jpp -w 20 --folds --path-style pointer", " ")); status != 0 {
+		t.Fatalf("status = %v: %v", status, errStream.String())
+	}
+	expected := `{"start":1,"end":4,"kind":"object","path":""}
+{"start":2,"end":2,"kind":"array","path":"/a"}
+{"start":3,"end":3,"kind":"object","path":"/b"}
+`
+	if outStream.String() != expected {
+		t.Errorf("actual=%v, expected: %v", outStream.String(), expected)
+	}
+}
+
+func TestRun_foldsExpandStrings(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{
+		inStream:  strings.NewReader(`{"a": "{\"b\": [1, 2]}", "c": [3]}`),
+		outStream: outStream,
+		errStream: errStream,
+	}
+	if status := c.run(strings.Split("jpp -w 10 --expand-strings --folds", " ")); status != 0 {
+		t.Fatalf("status = %v: %v", status, errStream.String())
+	}
+	expected := `{"start":1,"end":6,"kind":"object","path":"$"}
+{"start":2,"end":4,"kind":"object","path":"$.a"}
+{"start":3,"end":3,"kind":"array","path":"$.a.b"}
+{"start":5,"end":5,"kind":"array","path":"$.c"}
+`
+	if outStream.String() != expected {
+		t.Errorf("actual=%v, expected: %v", outStream.String(), expected)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignorePattern is a pattern in a .gitignore file.
+// See: https://git-scm.com/docs/gitignore#_pattern_format
+type ignorePattern struct {
+	pattern string
+	// negate re-includes the paths matched by the pattern ("!pattern").
+	negate bool
+	// dirOnly matches only directories ("pattern/").
+	dirOnly bool
+	// anchored matches the path relative to the directory of the
+	// .gitignore instead of the name at any level.
+	anchored bool
+}
+
+// gitignore is the patterns of a .gitignore file in dir.
+type gitignore struct {
+	dir      string
+	patterns []ignorePattern
+}
+
+// loadGitignore reads the .gitignore file in dir.
+// It returns nil if there is no .gitignore file.
+func loadGitignore(dir string) (*gitignore, error) {
+	f, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	g := &gitignore{dir: dir}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if p, ok := parseIgnorePattern(scanner.Text()); ok {
+			g.patterns = append(g.patterns, p)
+		}
+	}
+	return g, scanner.Err()
+}
+
+func parseIgnorePattern(line string) (ignorePattern, bool) {
+	line = strings.TrimRight(line, "\r")
+	if !strings.HasSuffix(line, "\\ ") {
+		line = strings.TrimRight(line, " ")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false
+	}
+	var p ignorePattern
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		p.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignorePattern{}, false
+	}
+	p.pattern = line
+	return p, true
+}
+
+// match reports whether the path is matched by g, and whether it is ignored.
+// The result of the last matching pattern wins.
+func (g *gitignore) match(filename string, isDir bool) (matched bool, ignored bool) {
+	rel, err := filepath.Rel(g.dir, filename)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false, false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, p := range g.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		var ok bool
+		if p.anchored {
+			ok = matchGlob(p.pattern, rel)
+		} else {
+			ok = matchGlob(p.pattern, path.Base(rel))
+		}
+		if ok {
+			matched, ignored = true, !p.negate
+		}
+	}
+	return matched, ignored
+}
+
+// ignoreList is the .gitignore files that apply to a directory,
+// from the outermost to the innermost.
+type ignoreList []*gitignore
+
+// ignored reports whether the path is ignored.
+// Patterns in deeper .gitignore files take precedence.
+func (l ignoreList) ignored(filename string, isDir bool) bool {
+	for i := len(l) - 1; i >= 0; i-- {
+		if matched, ignored := l[i].match(filename, isDir); matched {
+			return ignored
+		}
+	}
+	return false
+}
+
+// matchGlob reports whether the slash-separated name matches the pattern.
+// In addition to the syntax of path.Match, "**" matches zero or more
+// directories as in .gitignore.
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(patterns, names []string) bool {
+	for len(patterns) > 0 {
+		if patterns[0] == "**" {
+			// A trailing "**" matches everything inside, but not the directory itself.
+			start := 0
+			if len(patterns) == 1 {
+				start = 1
+			}
+			for i := start; i <= len(names); i++ {
+				if matchSegments(patterns[1:], names[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(names) == 0 {
+			return false
+		}
+		if ok, err := path.Match(patterns[0], names[0]); err != nil || !ok {
+			return false
+		}
+		patterns, names = patterns[1:], names[1:]
+	}
+	return len(names) == 0
+}
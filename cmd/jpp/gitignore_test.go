@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{"*.json", "a.json", true},
+		{"*.json", "dir/a.json", false},
+		{"dir/*.json", "dir/a.json", true},
+		{"**/a.json", "a.json", true},
+		{"**/a.json", "x/y/a.json", true},
+		{"dir/**", "dir/x/y.json", true},
+		{"dir/**", "dir", false},
+		{"a/**/b", "a/b", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**/b", "a/x/y/c", false},
+		{"a?.json", "ab.json", true},
+		{"[ab].json", "c.json", false},
+	}
+	for _, test := range tests {
+		if actual := matchGlob(test.pattern, test.name); actual != test.expected {
+			t.Errorf("matchGlob(%q, %q) = %v, expected: %v", test.pattern, test.name, actual, test.expected)
+		}
+	}
+}
+
+func TestGitignore(t *testing.T) {
+	g := &gitignore{dir: "/repo"}
+	for _, line := range []string{
+		"# comment",
+		"",
+		"*.json",
+		"!keep.json",
+		"/root.txt",
+		"build/",
+		"docs/**/*.md",
+	} {
+		if p, ok := parseIgnorePattern(line); ok {
+			g.patterns = append(g.patterns, p)
+		}
+	}
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"/repo/a.json", false, true},
+		{"/repo/sub/a.json", false, true},
+		{"/repo/sub/keep.json", false, false},
+		{"/repo/root.txt", false, true},
+		{"/repo/sub/root.txt", false, false},
+		{"/repo/build", true, true},
+		{"/repo/sub/build", true, true},
+		{"/repo/build", false, false},
+		{"/repo/docs/a/b/c.md", false, true},
+		{"/other/a.json", false, false},
+	}
+	for _, test := range tests {
+		if actual := (ignoreList{g}).ignored(test.path, test.isDir); actual != test.expected {
+			t.Errorf("ignored(%q, %v) = %v, expected: %v", test.path, test.isDir, actual, test.expected)
+		}
+	}
+}
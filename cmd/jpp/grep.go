@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/tanishiking/jpp"
+	"github.com/tidwall/gjson"
+)
+
+// matchColor emphasizes the matches of --highlight and --grep.
+var matchColor = jpp.SGR("1;30;43")
+
+// matchStyler emphasizes the parts of keys and strings that match re
+// without changing their widths, so that the layout stays the same.
+// Strings with escape sequences are emphasized as a whole if they match.
+type matchStyler struct {
+	base jpp.Styler
+	re   *regexp.Regexp
+}
+
+func (s matchStyler) Style(kind jpp.TokenKind, path jpp.Path, text string) (string, int) {
+	styled, width := s.base.Style(kind, path, text)
+	if kind != jpp.KeyToken && kind != jpp.StringToken {
+		return styled, width
+	}
+	var str string
+	if err := json.Unmarshal([]byte(text), &str); err != nil {
+		return styled, width
+	}
+	if inner := text[1 : len(text)-1]; inner != str {
+		if s.re.MatchString(str) {
+			return matchColor("%s", text), width
+		}
+		return styled, width
+	}
+
+	var b strings.Builder
+	prev := 0
+	for _, loc := range s.re.FindAllStringIndex(str, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		// The indices in text are shifted by the opening quote.
+		start, end := loc[0]+1, loc[1]+1
+		if prev < start {
+			seg, _ := s.base.Style(kind, path, text[prev:start])
+			b.WriteString(seg)
+		}
+		b.WriteString(matchColor("%s", text[start:end]))
+		prev = end
+	}
+	if prev == 0 {
+		return styled, width
+	}
+	seg, _ := s.base.Style(kind, path, text[prev:])
+	b.WriteString(seg)
+	return b.String(), width
+}
+
+// grepMatch is a value printed by --grep.
+type grepMatch struct {
+	path  jpp.Path
+	value gjson.Result
+}
+
+// grepValues returns the values that match re by their keys or scalar values,
+// or their ancestors of context levels up, in the order of the document.
+// Values inside other returned values are omitted.
+func grepValues(jsonStr string, re *regexp.Regexp, context int) []grepMatch {
+	var matches []grepMatch
+	var ancestors []gjson.Result
+	var walk func(path jpp.Path, key *string, j gjson.Result)
+	walk = func(path jpp.Path, key *string, j gjson.Result) {
+		ancestors = append(ancestors, j)
+		defer func() { ancestors = ancestors[:len(ancestors)-1] }()
+
+		matched := key != nil && re.MatchString(*key)
+		switch j.Type {
+		case gjson.JSON:
+		case gjson.String:
+			matched = matched || re.MatchString(j.Str)
+		default:
+			matched = matched || re.MatchString(j.Raw)
+		}
+		if matched {
+			level := len(path) - context
+			if level < 0 {
+				level = 0
+			}
+			target := path[:level]
+			if n := len(matches); n == 0 || !isPrefix(matches[n-1].path, target) {
+				// The values inside the new one were visited just before it.
+				for n > 0 && isPrefix(target, matches[n-1].path) {
+					n--
+				}
+				matches = append(matches[:n], grepMatch{path: target, value: ancestors[level]})
+			}
+		}
+		if j.IsArray() {
+			i := 0
+			j.ForEach(func(_, v gjson.Result) bool {
+				walk(path.Append(i), nil, v)
+				i++
+				return true
+			})
+		} else if j.IsObject() {
+			j.ForEach(func(k, v gjson.Result) bool {
+				walk(path.Append(k.Str), &k.Str, v)
+				return true
+			})
+		}
+	}
+	walk(jpp.Path{}, nil, gjson.Parse(jsonStr))
+	return matches
+}
+
+// isPrefix reports whether prefix is path itself or one of its ancestors.
+func isPrefix(prefix, path jpp.Path) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// grep formats the values of grepValues, each labeled with its path in
+// the style, which is decorated by color.
+func grep(jsonStr string, opts jpp.Options, re *regexp.Regexp, context int, style string, color jpp.ColoredFormat) ([]string, error) {
+	var results []string
+	for _, m := range grepValues(jsonStr, re, context) {
+		res, err := jpp.Format(m.value.Raw, opts)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, color("%s", formatPath(m.path, style)+":")+" "+res)
+	}
+	return results, nil
+}
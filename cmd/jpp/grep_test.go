@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/tanishiking/jpp"
+)
+
+func TestMatchStyler(t *testing.T) {
+	s := matchStyler{base: monochrome, re: regexp.MustCompile(`a+`)}
+	tests := []struct {
+		kind     jpp.TokenKind
+		text     string
+		expected string
+	}{
+		{jpp.StringToken, `"banana"`, `"b` + matchColor("%s", "a") + "n" + matchColor("%s", "a") + "n" + matchColor("%s", "a") + `"`},
+		{jpp.KeyToken, `"aa"`, `"` + matchColor("%s", "aa") + `"`},
+		{jpp.StringToken, `"x\"a"`, matchColor("%s", `"x\"a"`)},
+		{jpp.StringToken, `"xyz"`, `"xyz"`},
+		{jpp.NumberToken, `1`, `1`},
+		{jpp.PunctuationToken, `[`, `[`},
+	}
+	for _, test := range tests {
+		actual, width := s.Style(test.kind, nil, test.text)
+		if actual != test.expected || width != len([]rune(test.text)) {
+			t.Errorf("%v: got %q (%v), want %q", test.text, actual, width, test.expected)
+		}
+	}
+}
+
+func TestGrepValues(t *testing.T) {
+	jsonStr := `{"a": {"b": [1, "needle"], "needle": {"c": 2}}, "d": 10}`
+	tests := []struct {
+		pattern  string
+		context  int
+		expected []string
+	}{
+		{`needle`, 0, []string{`$.a.b[1]`, `$.a.needle`}},
+		{`needle`, 1, []string{`$.a`}},
+		{`^[12]$`, 1, []string{`$.a.b`, `$.a.needle`}},
+		{`needle`, 5, []string{`$`}},
+		{`^\d+$`, 0, []string{`$.a.b[0]`, `$.a.needle.c`, `$.d`}},
+		{`nothing`, 0, nil},
+	}
+	for _, test := range tests {
+		var actual []string
+		for _, m := range grepValues(jsonStr, regexp.MustCompile(test.pattern), test.context) {
+			actual = append(actual, m.path.String())
+		}
+		if strings.Join(actual, " ") != strings.Join(test.expected, " ") {
+			t.Errorf("%v with context %v: got %v, want %v", test.pattern, test.context, actual, test.expected)
+		}
+	}
+}
+
+func TestRun_grep(t *testing.T) {
+	input := `{"events": [{"id": 1, "user": {"name": "alice"}}, {"id": 2, "user": {"name": "bob"}}]}`
+	tests := []struct {
+		args     []string
+		status   int
+		expected string
+	}{
+		{[]string{"--grep", "bob"}, 0, "$.events[1].user.name: \"bob\"\n"},
+		{[]string{"--grep", "bob", "--context", "2", "--path-style", "pointer", "-w", "30"}, 0, `/events/1: {
+  "id": 2,
+  "user": {"name": "bob"}
+}
+`},
+		{[]string{"--grep", "carol"}, 1, ""},
+		{[]string{"--grep", "("}, 1, ""},
+		{[]string{"--grep", "bob", "--gron"}, 1, ""},
+		{[]string{"--highlight", "bob", "--format", "html"}, 1, ""},
+	}
+	for _, test := range tests {
+		outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+		c := &cli{inStream: strings.NewReader(input), outStream: outStream, errStream: errStream}
+		args := append([]string{"jpp", "--color", "never", "--no-config"}, test.args...)
+		if status := c.run(args); status != test.status {
+			t.Errorf("%v: status = %v, want %v: %v", test.args, status, test.status, errStream.String())
+		}
+		if outStream.String() != test.expected {
+			t.Errorf("%v: got\n%v\nwant\n%v", test.args, outStream.String(), test.expected)
+		}
+	}
+}
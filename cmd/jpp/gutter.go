@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tanishiking/jpp"
+)
+
+const (
+	pathStyleDotted  = "dotted"
+	pathStylePointer = "pointer"
+)
+
+// formatPath formats path in the style, dotted or pointer.
+func formatPath(path jpp.Path, style string) string {
+	if style == pathStylePointer {
+		return path.Pointer()
+	}
+	return path.String()
+}
+
+// linePaths returns the path of the first token on each line of res.
+func linePaths(res string, mappings []jpp.Mapping, style string) []string {
+	paths := make([]string, strings.Count(res, "\n")+1)
+	line, pos := 0, 0
+	labeled := false
+	for _, m := range mappings {
+		for ; pos < m.Output.Start; pos++ {
+			if res[pos] == '\n' {
+				line++
+				labeled = false
+			}
+		}
+		if labeled {
+			continue
+		}
+		paths[line] = formatPath(m.Path, style)
+		labeled = true
+	}
+	return paths
+}
+
+// addGutter prepends the labels padded to the same width to the lines of res.
+// The gutter is decorated by color.
+func addGutter(res string, labels []string, color jpp.ColoredFormat) string {
+	width := 0
+	for _, label := range labels {
+		if w := len([]rune(label)); w > width {
+			width = w
+		}
+	}
+	lines := strings.Split(res, "\n")
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		label := labels[i] + strings.Repeat(" ", width-len([]rune(labels[i])))
+		b.WriteString(color("%s", label+" │ "))
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// lineNumbers returns the line numbers of n lines aligned to the right.
+func lineNumbers(n int) []string {
+	width := len(strconv.Itoa(n))
+	labels := make([]string, n)
+	for i := range labels {
+		num := strconv.Itoa(i + 1)
+		labels[i] = strings.Repeat(" ", width-len(num)) + num
+	}
+	return labels
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tanishiking/jpp"
+)
+
+func TestLinePaths(t *testing.T) {
+	src := `{"items": [{"name": "x", "tags": ["a", "b"]}], "a/b": 1}`
+	res, mappings, err := jpp.FormatWithSourceMap(src, jpp.Options{Indent: "  ", Width: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		style    string
+		expected []string
+	}{
+		{pathStyleDotted, []string{"$", "$.items", "$.items[0]", "$.items[0].name", "$.items[0].tags", "$.items[0]", "$.items", `$["a/b"]`, "$"}},
+		{pathStylePointer, []string{"", "/items", "/items/0", "/items/0/name", "/items/0/tags", "/items/0", "/items", "/a~1b", ""}},
+	}
+	for _, test := range tests {
+		actual := linePaths(res, mappings, test.style)
+		if strings.Join(actual, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("%v: got %q for\n%v", test.style, actual, res)
+		}
+	}
+}
+
+func TestRun_paths(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{
+		inStream:  strings.NewReader(`{"a": [1, 2, 3, 4], "b": null}`),
+		outStream: outStream,
+		errStream: errStream,
+	}
+	// The gutter doesn't count against the width.
+	if status := c.run(strings.Split("jpp -w 20 --color never --paths", " ")); status != 0 {
+		t.Fatalf("status = %v: %v", status, errStream.String())
+	}
+	expected := `$   │ {
+$.a │   "a": [1, 2, 3, 4],
+$.b │   "b": null
+$   │ }
+`
+	if outStream.String() != expected {
+		t.Errorf("actual=%v, expected: %v", outStream.String(), expected)
+	}
+
+	c.inStream = strings.NewReader(`{}`)
+	if status := c.run(strings.Split("jpp -w 20 --paths --path-style slash", " ")); status != 1 {
+		t.Errorf("status = %v for an invalid --path-style, want 1", status)
+	}
+}
+
+func TestRun_lineNumbers(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{
+		inStream:  strings.NewReader(`[[1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20]]`),
+		outStream: outStream,
+		errStream: errStream,
+	}
+	if status := c.run(strings.Split("jpp -w 20 --color never --line-numbers", " ")); status != 0 {
+		t.Fatalf("status = %v: %v", status, errStream.String())
+	}
+	expected := `1 │ [
+2 │   [
+3 │     1, 2, 3, 4, 5,
+4 │     6, 7, 8, 9, 10,
+5 │     11, 12, 13, 14,
+6 │     15, 16, 17, 18,
+7 │     19, 20
+8 │   ]
+9 │ ]
+`
+	if outStream.String() != expected {
+		t.Errorf("actual=%v, expected: %v", outStream.String(), expected)
+	}
+}
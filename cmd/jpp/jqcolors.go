@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/tanishiking/jpp"
+)
+
+// parseJQColors maps JQ_COLORS onto jpp.ColorScheme.
+// JQ_COLORS is a colon-separated list of SGR parameters for
+// null:false:true:numbers:strings:arrays:objects:fields.
+// See: https://jqlang.github.io/jq/manual/#colors
+//
+// jpp has a single color for booleans, so the color for `true` is used.
+// Arrays and objects are ignored because jpp doesn't color brackets.
+// Missing entries fall back to the ones in base.
+// ok is false if JQ_COLORS is empty or malformed.
+func parseJQColors(jqColors string, base *jpp.ColorScheme) (scheme *jpp.ColorScheme, ok bool) {
+	if jqColors == "" {
+		return nil, false
+	}
+	sgrs := strings.Split(jqColors, ":")
+	if len(sgrs) > 8 {
+		return nil, false
+	}
+	for _, sgr := range sgrs {
+		if !jpp.IsValidSGR(sgr) {
+			return nil, false
+		}
+	}
+
+	s := *base
+	scheme = &s
+	targets := []*jpp.ColoredFormat{
+		&scheme.Null,
+		nil, // false
+		&scheme.Bool,
+		&scheme.Number,
+		&scheme.String,
+		nil, // arrays
+		nil, // objects
+		&scheme.FieldName,
+	}
+	for i, sgr := range sgrs {
+		if targets[i] != nil {
+			*targets[i] = jpp.SGR(sgr)
+		}
+	}
+	return scheme, true
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/tanishiking/jpp"
+)
+
+func TestParseJQColors(t *testing.T) {
+	scheme, ok := parseJQColors("0;90:0;37:0;31:0;33:0;32:1;37:1;37:34;1", monochrome)
+	if !ok {
+		t.Fatal("expected JQ_COLORS to be parsed")
+	}
+	tests := []struct {
+		color    jpp.ColoredFormat
+		expected string
+	}{
+		{scheme.Null, "\x1b[0;90mnull\x1b[0m"},
+		{scheme.Bool, "\x1b[0;31mnull\x1b[0m"},
+		{scheme.Number, "\x1b[0;33mnull\x1b[0m"},
+		{scheme.String, "\x1b[0;32mnull\x1b[0m"},
+		{scheme.FieldName, "\x1b[34;1mnull\x1b[0m"},
+	}
+	for _, test := range tests {
+		if actual := test.color("null"); actual != test.expected {
+			t.Errorf("expected: %q, actual: %q", test.expected, actual)
+		}
+	}
+}
+
+func TestParseJQColors_Partial(t *testing.T) {
+	scheme, ok := parseJQColors("1;30", monochrome)
+	if !ok {
+		t.Fatal("expected JQ_COLORS to be parsed")
+	}
+	if actual := scheme.Null("null"); actual != "\x1b[1;30mnull\x1b[0m" {
+		t.Errorf("actual: %q", actual)
+	}
+	if actual := scheme.FieldName("foo"); actual != "foo" {
+		t.Errorf("expected the base color for fields, actual: %q", actual)
+	}
+}
+
+func TestParseJQColors_Invalid(t *testing.T) {
+	for _, jqColors := range []string{"", "red", "1;31:1;31:1;31:1;31:1;31:1;31:1;31:1;31:1;31"} {
+		if _, ok := parseJQColors(jqColors, monochrome); ok {
+			t.Errorf("expected %q to be rejected", jqColors)
+		}
+	}
+}
+
+func TestDefaultCLIScheme_JPPTakesPrecedence(t *testing.T) {
+	defer os.Setenv("JQ_COLORS", os.Getenv("JQ_COLORS"))
+	defer os.Setenv("JPP_NULL", os.Getenv("JPP_NULL"))
+
+	os.Setenv("JQ_COLORS", "1;31")
+	os.Setenv("JPP_NULL", "")
+	if actual := defaultCLIScheme().Null("null"); actual != "\x1b[1;31mnull\x1b[0m" {
+		t.Errorf("expected JQ_COLORS to be used, actual: %q", actual)
+	}
+
+	os.Setenv("JPP_NULL", "cyan")
+	if actual := defaultCLIScheme().Null("null"); actual != jpp.Cyan("null") {
+		t.Errorf("expected JPP_NULL to be used, actual: %q", actual)
+	}
+}
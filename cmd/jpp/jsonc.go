@@ -0,0 +1,50 @@
+package main
+
+// stripJSONC replaces the comments and the trailing commas in the JSONC
+// (JSON with comments) src with spaces, keeping the byte offsets and the
+// line breaks. It also reports whether src contains comments.
+func stripJSONC(src string) (stripped string, hasComments bool) {
+	b := []byte(src)
+	// lastComma is the offset of the last comma that may be trailing.
+	lastComma := -1
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; {
+		case c == '"':
+			lastComma = -1
+			for i++; i < len(b) && b[i] != '"'; i++ {
+				if b[i] == '\\' {
+					i++
+				}
+			}
+		case c == '/' && i+1 < len(b) && b[i+1] == '/':
+			hasComments = true
+			for ; i < len(b) && b[i] != '\n'; i++ {
+				b[i] = ' '
+			}
+		case c == '/' && i+1 < len(b) && b[i+1] == '*':
+			hasComments = true
+			b[i], b[i+1] = ' ', ' '
+			for i += 2; i < len(b); i++ {
+				if b[i] == '*' && i+1 < len(b) && b[i+1] == '/' {
+					b[i], b[i+1] = ' ', ' '
+					i++
+					break
+				}
+				if b[i] != '\n' && b[i] != '\r' {
+					b[i] = ' '
+				}
+			}
+		case c == ',':
+			lastComma = i
+		case c == ']' || c == '}':
+			if lastComma >= 0 {
+				b[lastComma] = ' '
+			}
+			lastComma = -1
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			lastComma = -1
+		}
+	}
+	return string(b), hasComments
+}
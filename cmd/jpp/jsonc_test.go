@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestStripJSONC(t *testing.T) {
+	tests := []struct {
+		src         string
+		expected    string
+		hasComments bool
+	}{
+		{`{"a": 1}`, `{"a": 1}`, false},
+		{`[1, 2,]`, `[1, 2 ]`, false},
+		{"{\"a\": 1, // one\n}", "{\"a\": 1        \n}", true},
+		{"/* a\nb */[]", "    \n    []", true},
+		{`["//", "/*", ",]"]`, `["//", "/*", ",]"]`, false},
+	}
+	for _, test := range tests {
+		actual, hasComments := stripJSONC(test.src)
+		if actual != test.expected || hasComments != test.hasComments {
+			t.Errorf("stripJSONC(%q) = %q, %v, want %q, %v", test.src, actual, hasComments, test.expected, test.hasComments)
+		}
+	}
+}
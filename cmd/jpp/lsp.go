@@ -0,0 +1,473 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/tanishiking/jpp"
+)
+
+// JSON-RPC error codes.
+// See: https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#errorCodes
+const (
+	lspParseError     = -32700
+	lspInvalidParams  = -32602
+	lspMethodNotFound = -32601
+	lspRequestFailed  = -32803
+)
+
+type lspRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *lspError) Error() string {
+	return e.Message
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspTextEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspTextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Text       string `json:"text"`
+}
+
+type lspTextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type lspFormattingOptions struct {
+	TabSize      int  `json:"tabSize"`
+	InsertSpaces bool `json:"insertSpaces"`
+}
+
+type lspFormattingParams struct {
+	TextDocument lspTextDocumentIdentifier `json:"textDocument"`
+	Range        *lspRange                 `json:"range,omitempty"`
+	Position     *lspPosition              `json:"position,omitempty"`
+	Options      lspFormattingOptions      `json:"options"`
+}
+
+// lspDocument is a document opened in the editor.
+type lspDocument struct {
+	uri        string
+	languageID string
+	text       string
+}
+
+func (d *lspDocument) isJSONC() bool {
+	return d.languageID == "jsonc" || strings.HasSuffix(d.uri, ".jsonc")
+}
+
+// lspServer is a Language Server Protocol server that formats JSON and
+// JSONC documents with jpp, and publishes their parse errors.
+type lspServer struct {
+	in       *bufio.Reader
+	out      io.Writer
+	docs     map[string]*lspDocument
+	resolver *configResolver
+	shutdown bool
+}
+
+func newLSPServer(in io.Reader, out io.Writer) *lspServer {
+	return &lspServer{
+		in:       bufio.NewReader(in),
+		out:      out,
+		docs:     map[string]*lspDocument{},
+		resolver: newConfigResolver(),
+	}
+}
+
+// runLSP runs `jpp lsp`, which serves LSP over stdin and stdout.
+func (c *cli) runLSP(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(c.errStream, "usage: jpp lsp")
+		return 1
+	}
+	s := newLSPServer(c.inStream, c.outStream)
+	if err := s.serve(); err != nil {
+		fmt.Fprintln(c.errStream, err.Error())
+		return 1
+	}
+	if !s.shutdown {
+		// The client exited without the shutdown request.
+		return 1
+	}
+	return 0
+}
+
+// serve handles messages until the exit notification or the end of the input.
+func (s *lspServer) serve() error {
+	for {
+		body, err := s.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req lspRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.respond(nil, nil, &lspError{Code: lspParseError, Message: err.Error()})
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+		result, lerr := s.handle(&req)
+		// Notifications don't have IDs and are not responded.
+		if req.ID != nil {
+			s.respond(req.ID, result, lerr)
+		}
+	}
+}
+
+// read reads the body of a message framed by the Content-Length header.
+func (s *lspServer) read() ([]byte, error) {
+	length := -1
+	for {
+		line, err := s.in.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		if i := strings.Index(line, ":"); i >= 0 && strings.EqualFold(line[:i], "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(line[i+1:]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid Content-Length: %v", line)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("missing Content-Length")
+	}
+	body := make([]byte, length)
+	_, err := io.ReadFull(s.in, body)
+	return body, err
+}
+
+func (s *lspServer) write(msg map[string]interface{}) {
+	msg["jsonrpc"] = "2.0"
+	body, _ := json.Marshal(msg)
+	fmt.Fprintf(s.out, "Content-Length: %v\r\n\r\n%s", len(body), body)
+}
+
+func (s *lspServer) respond(id json.RawMessage, result interface{}, lerr *lspError) {
+	msg := map[string]interface{}{"id": id}
+	if lerr != nil {
+		msg["error"] = lerr
+	} else {
+		msg["result"] = result
+	}
+	s.write(msg)
+}
+
+func (s *lspServer) notify(method string, params interface{}) {
+	s.write(map[string]interface{}{"method": method, "params": params})
+}
+
+func (s *lspServer) handle(req *lspRequest) (interface{}, *lspError) {
+	switch req.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				// Full document sync.
+				"textDocumentSync":                1,
+				"documentFormattingProvider":      true,
+				"documentRangeFormattingProvider": true,
+				"documentOnTypeFormattingProvider": map[string]interface{}{
+					"firstTriggerCharacter": "}",
+					"moreTriggerCharacter":  []string{"]"},
+				},
+			},
+			"serverInfo": map[string]interface{}{"name": "jpp"},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params struct {
+			TextDocument lspTextDocumentItem `json:"textDocument"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &lspError{Code: lspInvalidParams, Message: err.Error()}
+		}
+		doc := &lspDocument{
+			uri:        params.TextDocument.URI,
+			languageID: params.TextDocument.LanguageID,
+			text:       params.TextDocument.Text,
+		}
+		s.docs[doc.uri] = doc
+		s.publishDiagnostics(doc)
+		return nil, nil
+	case "textDocument/didChange":
+		var params struct {
+			TextDocument   lspTextDocumentIdentifier `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &lspError{Code: lspInvalidParams, Message: err.Error()}
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok || len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		doc.text = params.ContentChanges[len(params.ContentChanges)-1].Text
+		s.publishDiagnostics(doc)
+		return nil, nil
+	case "textDocument/didClose":
+		var params struct {
+			TextDocument lspTextDocumentIdentifier `json:"textDocument"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &lspError{Code: lspInvalidParams, Message: err.Error()}
+		}
+		delete(s.docs, params.TextDocument.URI)
+		s.notify("textDocument/publishDiagnostics", map[string]interface{}{
+			"uri":         params.TextDocument.URI,
+			"diagnostics": []lspDiagnostic{},
+		})
+		return nil, nil
+	case "textDocument/formatting", "textDocument/rangeFormatting", "textDocument/onTypeFormatting":
+		var params lspFormattingParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &lspError{Code: lspInvalidParams, Message: err.Error()}
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok {
+			return nil, &lspError{Code: lspInvalidParams, Message: "unknown document: " + params.TextDocument.URI}
+		}
+		var edits []lspTextEdit
+		var err error
+		switch {
+		case req.Method == "textDocument/rangeFormatting" && params.Range != nil:
+			edits, err = s.formatRange(doc, *params.Range, params.Options)
+		case req.Method == "textDocument/onTypeFormatting" && params.Position != nil:
+			// Only the value closed by the typed character is formatted.
+			edits, err = s.formatRange(doc, lspRange{Start: *params.Position, End: *params.Position}, params.Options)
+		default:
+			edits, err = s.format(doc, params.Options)
+		}
+		if err != nil {
+			if req.Method == "textDocument/onTypeFormatting" {
+				// The document is often incomplete while typing.
+				return []lspTextEdit{}, nil
+			}
+			return nil, &lspError{Code: lspRequestFailed, Message: err.Error()}
+		}
+		return edits, nil
+	default:
+		if req.ID == nil {
+			// Unknown notifications are ignored.
+			return nil, nil
+		}
+		return nil, &lspError{Code: lspMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+// source returns the JSON text of the document to format,
+// where the trailing commas in JSONC are removed.
+func (s *lspServer) source(doc *lspDocument) (string, error) {
+	src := doc.text
+	if doc.isJSONC() {
+		stripped, hasComments := stripJSONC(src)
+		if hasComments {
+			return stripped, fmt.Errorf("jpp can't format documents with comments")
+		}
+		src = stripped
+	}
+	return src, nil
+}
+
+func (s *lspServer) publishDiagnostics(doc *lspDocument) {
+	src := doc.text
+	if doc.isJSONC() {
+		src, _ = stripJSONC(src)
+	}
+	diagnostics := []lspDiagnostic{}
+	if serr := checkSyntax(src); serr != nil {
+		end := serr.offset
+		if end < len(src) {
+			_, size := utf8.DecodeRuneInString(src[end:])
+			end += size
+		}
+		diagnostics = append(diagnostics, lspDiagnostic{
+			Range: lspRange{
+				Start: offsetToPosition(doc.text, serr.offset),
+				End:   offsetToPosition(doc.text, end),
+			},
+			Severity: 1, // Error
+			Source:   "jpp",
+			Message:  serr.msg,
+		})
+	}
+	s.notify("textDocument/publishDiagnostics", map[string]interface{}{
+		"uri":         doc.uri,
+		"diagnostics": diagnostics,
+	})
+}
+
+// settings returns the settings to format the document. The configuration
+// files for the document take precedence over the options of the editor.
+func (s *lspServer) settings(doc *lspDocument, opts lspFormattingOptions) (formatSettings, error) {
+	base := formatSettings{
+		width:    defaultFileWidth,
+		indent:   "  ",
+		tabWidth: jpp.DefaultTabWidth,
+	}
+	if opts.TabSize > 0 {
+		base.tabWidth = opts.TabSize
+		if opts.InsertSpaces {
+			base.indent = strings.Repeat(" ", opts.TabSize)
+		}
+	}
+	if !opts.InsertSpaces {
+		base.indent = "\t"
+	}
+	u, err := url.Parse(doc.uri)
+	if err != nil || u.Scheme != "file" {
+		return base, nil
+	}
+	return s.resolver.resolve(filepath.FromSlash(u.Path), base)
+}
+
+// format returns the edits to format the whole document.
+func (s *lspServer) format(doc *lspDocument, opts lspFormattingOptions) ([]lspTextEdit, error) {
+	src, err := s.source(doc)
+	if err != nil {
+		return nil, err
+	}
+	if serr := checkSyntax(src); serr != nil {
+		return nil, serr
+	}
+	fs, err := s.settings(doc, opts)
+	if err != nil {
+		return nil, err
+	}
+	jppOpts, finalNewline := fs.options(src)
+	res, err := jpp.Format(src, jppOpts)
+	if err != nil {
+		return nil, err
+	}
+	if finalNewline {
+		res += "\n"
+	}
+	// The edit keeps the cursor and the folds in the editor as much as possible.
+	return textEdits(doc, jpp.MinimalEdit(doc.text, res, 0)), nil
+}
+
+// formatRange returns the edit to format the smallest value enclosing r.
+func (s *lspServer) formatRange(doc *lspDocument, r lspRange, opts lspFormattingOptions) ([]lspTextEdit, error) {
+	src, err := s.source(doc)
+	if err != nil {
+		return nil, err
+	}
+	if serr := checkSyntax(src); serr != nil {
+		return nil, serr
+	}
+	fs, err := s.settings(doc, opts)
+	if err != nil {
+		return nil, err
+	}
+	jppOpts, _ := fs.options(src)
+	// Removing trailing commas keeps the offsets, so the edit applies to the document.
+	edit, err := jpp.FormatRange(src, positionToOffset(doc.text, r.Start), positionToOffset(doc.text, r.End), jppOpts)
+	if err != nil {
+		return nil, err
+	}
+	return textEdits(doc, edit), nil
+}
+
+// textEdits converts the edit of the document to the LSP text edits.
+func textEdits(doc *lspDocument, edit jpp.TextEdit) []lspTextEdit {
+	if edit.Start == edit.End && edit.NewText == "" {
+		return []lspTextEdit{}
+	}
+	return []lspTextEdit{{
+		Range: lspRange{
+			Start: offsetToPosition(doc.text, edit.Start),
+			End:   offsetToPosition(doc.text, edit.End),
+		},
+		NewText: edit.NewText,
+	}}
+}
+
+// offsetToPosition converts the byte offset in text to the LSP position,
+// whose character is counted in UTF-16 code units.
+func offsetToPosition(text string, offset int) lspPosition {
+	if offset > len(text) {
+		offset = len(text)
+	}
+	var pos lspPosition
+	for _, r := range text[:offset] {
+		if r == '\n' {
+			pos.Line++
+			pos.Character = 0
+			continue
+		}
+		pos.Character += len(utf16.Encode([]rune{r}))
+	}
+	return pos
+}
+
+// positionToOffset converts the LSP position in text to the byte offset.
+// Positions beyond the line or the text are clamped to their end.
+func positionToOffset(text string, pos lspPosition) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		offset += i + 1
+	}
+	for units := 0; offset < len(text) && text[offset] != '\n'; {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		units += len(utf16.Encode([]rune{r}))
+		if units > pos.Character {
+			break
+		}
+		offset += size
+	}
+	return offset
+}
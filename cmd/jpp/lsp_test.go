@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tanishiking/jpp"
+)
+
+// lspSession runs the server on the messages and returns the messages it wrote.
+func lspSession(t *testing.T, messages ...string) (int, []map[string]interface{}) {
+	var in bytes.Buffer
+	for _, msg := range messages {
+		fmt.Fprintf(&in, "Content-Length: %v\r\n\r\n%s", len(msg), msg)
+	}
+	out := new(bytes.Buffer)
+	c := &cli{inStream: &in, outStream: out, errStream: new(bytes.Buffer)}
+	status := c.run([]string{"jpp", "lsp"})
+
+	var res []map[string]interface{}
+	s := newLSPServer(out, nil)
+	for {
+		body, err := s.read()
+		if err != nil {
+			break
+		}
+		var msg map[string]interface{}
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, msg)
+	}
+	return status, res
+}
+
+func lspDidOpen(uri, languageID, text string) string {
+	params, _ := json.Marshal(map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "languageId": languageID, "version": 1, "text": text},
+	})
+	return fmt.Sprintf(`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":%s}`, params)
+}
+
+func lspFormatting(id int, uri string) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%v,"method":"textDocument/formatting","params":{"textDocument":{"uri":%q},"options":{"tabSize":4,"insertSpaces":true}}}`, id, uri)
+}
+
+func lspEdits(t *testing.T, response map[string]interface{}) []lspTextEdit {
+	result, _ := json.Marshal(response["result"])
+	var edits []lspTextEdit
+	if err := json.Unmarshal(result, &edits); err != nil {
+		t.Fatalf("response = %v: %v", response, err)
+	}
+	return edits
+}
+
+func TestLSP(t *testing.T) {
+	dir := setupTree(t, map[string]string{
+		".jpp.json": `{"width": 20}`,
+	})
+	defer os.RemoveAll(dir)
+	uri := "file://" + filepath.ToSlash(filepath.Join(dir, "a.json"))
+
+	status, msgs := lspSession(t,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		lspDidOpen(uri, "json", `{"a": [1, 2, 3], "b": {"c": "ã"}}`),
+		lspFormatting(2, uri),
+		`{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":`+fmt.Sprintf("%q", uri)+`},"contentChanges":[{"text":"{\"a\" 1}"}]}}`,
+		lspFormatting(3, uri),
+		`{"jsonrpc":"2.0","id":4,"method":"unknown"}`,
+		`{"jsonrpc":"2.0","id":5,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	)
+	if status != 0 {
+		t.Errorf("status = %v, want 0", status)
+	}
+	if len(msgs) != 7 {
+		t.Fatalf("got %v messages: %v", len(msgs), msgs)
+	}
+
+	caps := msgs[0]["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+	if caps["documentFormattingProvider"] != true {
+		t.Errorf("capabilities = %v", caps)
+	}
+	if diags := msgs[1]["params"].(map[string]interface{})["diagnostics"].([]interface{}); len(diags) != 0 {
+		t.Errorf("diagnostics = %v, want none", diags)
+	}
+
+	edits := lspEdits(t, msgs[2])
+	expected := []lspTextEdit{{
+		Range:   lspRange{Start: lspPosition{0, 1}, End: lspPosition{0, 33}},
+		NewText: "\n    \"a\": [1, 2, 3],\n    \"b\": {\"c\": \"ã\"}\n}\n",
+	}}
+	if !reflect.DeepEqual(edits, expected) {
+		t.Errorf("edits = %v, want %v", edits, expected)
+	}
+
+	diags := msgs[3]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	if len(diags) != 1 {
+		t.Fatalf("diagnostics = %v, want one", diags)
+	}
+	r, _ := json.Marshal(diags[0].(map[string]interface{})["range"])
+	if string(r) != `{"end":{"character":6,"line":0},"start":{"character":5,"line":0}}` {
+		t.Errorf("range of the diagnostic = %s", r)
+	}
+
+	for i, code := range []float64{lspRequestFailed, lspMethodNotFound} {
+		lerr, ok := msgs[4+i]["error"].(map[string]interface{})
+		if !ok || lerr["code"] != code {
+			t.Errorf("response = %v, want error %v", msgs[4+i], code)
+		}
+	}
+	if v, ok := msgs[6]["result"]; !ok || v != nil {
+		t.Errorf("response to shutdown = %v", msgs[6])
+	}
+}
+
+func TestLSPJSONC(t *testing.T) {
+	_, msgs := lspSession(t,
+		lspDidOpen("untitled:a", "jsonc", "[1, 2,]"),
+		lspFormatting(1, "untitled:a"),
+		lspDidOpen("untitled:b.jsonc", "json", "[1] // one"),
+		lspFormatting(2, "untitled:b.jsonc"),
+		lspDidOpen("untitled:c", "jsonc", "[1 2] // one"),
+	)
+	if len(msgs) != 5 {
+		t.Fatalf("got %v messages: %v", len(msgs), msgs)
+	}
+	for i, n := range []int{0, 0, 1} {
+		if diags := msgs[2*i]["params"].(map[string]interface{})["diagnostics"].([]interface{}); len(diags) != n {
+			t.Errorf("diagnostics = %v, want %v", diags, n)
+		}
+	}
+	edits := lspEdits(t, msgs[1])
+	expected := []lspTextEdit{{
+		Range:   lspRange{Start: lspPosition{0, 5}, End: lspPosition{0, 7}},
+		NewText: "]\n",
+	}}
+	if !reflect.DeepEqual(edits, expected) {
+		t.Errorf("edits = %v, want %v", edits, expected)
+	}
+	lerr, _ := msgs[3]["error"].(map[string]interface{})
+	if lerr == nil || !strings.Contains(lerr["message"].(string), "comments") {
+		t.Errorf("response = %v, want an error for comments", msgs[3])
+	}
+}
+
+func TestLSPRangeFormatting(t *testing.T) {
+	text := "{\n  \"a\": [1,2],\n  \"b\": [3,4]\n}"
+	_, msgs := lspSession(t,
+		lspDidOpen("untitled:a", "json", text),
+		`{"jsonrpc":"2.0","id":1,"method":"textDocument/rangeFormatting","params":{"textDocument":{"uri":"untitled:a"},`+
+			`"range":{"start":{"line":2,"character":9},"end":{"line":2,"character":10}},"options":{"tabSize":2,"insertSpaces":true}}}`,
+	)
+	if len(msgs) != 2 {
+		t.Fatalf("got %v messages: %v", len(msgs), msgs)
+	}
+	edits := lspEdits(t, msgs[1])
+	expected := []lspTextEdit{{
+		Range:   lspRange{Start: lspPosition{2, 10}, End: lspPosition{2, 10}},
+		NewText: " ",
+	}}
+	if !reflect.DeepEqual(edits, expected) {
+		t.Errorf("edits = %v, want %v", edits, expected)
+	}
+}
+
+func TestLSPOnTypeFormatting(t *testing.T) {
+	text := "{\n  \"a\": [1,2],\n  \"b\": {\"c\":3}\n}"
+	_, msgs := lspSession(t,
+		lspDidOpen("untitled:a", "json", text),
+		`{"jsonrpc":"2.0","id":1,"method":"textDocument/onTypeFormatting","params":{"textDocument":{"uri":"untitled:a"},`+
+			`"position":{"line":2,"character":14},"ch":"}","options":{"tabSize":2,"insertSpaces":true}}}`,
+	)
+	if len(msgs) != 2 {
+		t.Fatalf("got %v messages: %v", len(msgs), msgs)
+	}
+	edits := lspEdits(t, msgs[1])
+	expected := []lspTextEdit{{
+		Range:   lspRange{Start: lspPosition{2, 12}, End: lspPosition{2, 12}},
+		NewText: " ",
+	}}
+	if !reflect.DeepEqual(edits, expected) {
+		t.Errorf("edits = %v, want %v", edits, expected)
+	}
+}
+
+func TestPositionToOffset(t *testing.T) {
+	text := "[\n\"😀α\",\n1]"
+	tests := []struct {
+		pos      lspPosition
+		expected int
+	}{
+		{lspPosition{0, 0}, 0},
+		{lspPosition{1, 1}, 3},
+		{lspPosition{1, 3}, 7},
+		{lspPosition{1, 4}, 9},
+		{lspPosition{1, 100}, 11},
+		{lspPosition{2, 1}, 13},
+		{lspPosition{5, 0}, 14},
+	}
+	for _, test := range tests {
+		if actual := positionToOffset(text, test.pos); actual != test.expected {
+			t.Errorf("positionToOffset(%v) = %v, want %v", test.pos, actual, test.expected)
+		}
+		if test.pos.Line < 2 && test.pos.Character < 5 {
+			if actual := offsetToPosition(text, test.expected); actual != test.pos {
+				t.Errorf("offsetToPosition(%v) = %v, want %v", test.expected, actual, test.pos)
+			}
+		}
+	}
+}
+
+func TestTextEdits(t *testing.T) {
+	tests := []struct {
+		old, new string
+		expected []lspTextEdit
+	}{
+		{"[1]", "[1]", []lspTextEdit{}},
+		{"[\"α\",\n\"😀\"]", "[\"α\", \"😀\"]", []lspTextEdit{
+			{Range: lspRange{Start: lspPosition{0, 5}, End: lspPosition{1, 0}}, NewText: " "},
+		}},
+		{"[\"😀\" ]", "[\"😀\"]", []lspTextEdit{
+			{Range: lspRange{Start: lspPosition{0, 5}, End: lspPosition{0, 6}}, NewText: ""},
+		}},
+		{"[\"😀\"]", "[\"😀\"]\n", []lspTextEdit{
+			{Range: lspRange{Start: lspPosition{0, 6}, End: lspPosition{0, 6}}, NewText: "\n"},
+		}},
+	}
+	for _, test := range tests {
+		actual := textEdits(&lspDocument{text: test.old}, jpp.MinimalEdit(test.old, test.new, 0))
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("textEdits(%q, %q) = %v, want %v", test.old, test.new, actual, test.expected)
+		}
+	}
+}
+
+func TestLSPFinalNewline(t *testing.T) {
+	_, msgs := lspSession(t,
+		lspDidOpen("untitled:a", "json", `{"a": 1}`),
+		lspFormatting(1, "untitled:a"),
+	)
+	if len(msgs) != 2 {
+		t.Fatalf("got %v messages: %v", len(msgs), msgs)
+	}
+	edits := lspEdits(t, msgs[1])
+	expected := []lspTextEdit{{
+		Range:   lspRange{Start: lspPosition{0, 8}, End: lspPosition{0, 8}},
+		NewText: "\n",
+	}}
+	if !reflect.DeepEqual(edits, expected) {
+		t.Errorf("edits = %v, want %v", edits, expected)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/tanishiking/jpp"
+)
+
+// redactFlags are the flags to redact sensitive values.
+// The patterns given by the flags are added to the built-in ones.
+type redactFlags struct {
+	redact bool
+	keys   stringsFlag
+	paths  stringsFlag
+	values stringsFlag
+}
+
+func (r *redactFlags) register(flags *flag.FlagSet) {
+	flags.BoolVar(&r.redact, "redact", false, "replace passwords, tokens and other sensitive values with \""+jpp.RedactedValue+"\"")
+	flags.Var(&r.keys, "redact-key", "glob pattern of the keys whose values are redacted, such as '*token*' (repeatable, implies --redact)")
+	flags.Var(&r.paths, "redact-path", "path of the values redacted, such as '$.users[*].email' (repeatable, implies --redact)")
+	flags.Var(&r.values, "redact-value", "regular expression of the strings redacted (repeatable, implies --redact)")
+}
+
+// redaction returns the redaction of the flags, or nil if none of them is given.
+func (r *redactFlags) redaction() (*jpp.Redaction, error) {
+	if !r.redact && len(r.keys) == 0 && len(r.paths) == 0 && len(r.values) == 0 {
+		return nil, nil
+	}
+	keys := append(append([]string{}, jpp.DefaultRedactedKeys...), r.keys...)
+	values := append(append([]string{}, jpp.DefaultRedactedValues...), r.values...)
+	return jpp.NewRedaction(keys, r.paths, values)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRun_redact(t *testing.T) {
+	input := `{"user": {"name": "alice", "password": "hunter2", "email": "alice@example.com"}}`
+	tests := []struct {
+		args     []string
+		status   int
+		expected string
+	}{
+		{[]string{"--redact"}, 0, `{
+  "user": {"name": "alice", "password": "[REDACTED]", "email": "alice@example.com"}
+}
+`},
+		{[]string{"--redact-path", "/user/email"}, 0, `{
+  "user": {"name": "alice", "password": "[REDACTED]", "email": "[REDACTED]"}
+}
+`},
+		{[]string{"--redact-key", "NAME", "--grep", "alice"}, 0, "$.user.email: \"alice@example.com\"\n"},
+		{[]string{"--redact-value", "("}, 1, ""},
+		{[]string{"--redact", "--check"}, 1, ""},
+	}
+	for _, test := range tests {
+		outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+		c := &cli{inStream: strings.NewReader(input), outStream: outStream, errStream: errStream}
+		args := append([]string{"jpp", "--color", "never", "--no-config", "-w", "100"}, test.args...)
+		if status := c.run(args); status != test.status {
+			t.Errorf("%v: status = %v, want %v: %v", test.args, status, test.status, errStream.String())
+		}
+		if outStream.String() != test.expected {
+			t.Errorf("%v: got\n%v\nwant\n%v", test.args, outStream.String(), test.expected)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/tanishiking/jpp"
+	"github.com/tidwall/gjson"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// maxEnum is the maximum number of distinct strings inferred as an enum.
+const maxEnum = 8
+
+// schemaDraft is the dialect of the inferred schemas.
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// runSchema runs `jpp schema`, which infers a JSON Schema from the samples
+// given as files or the standard input.
+func (c *cli) runSchema(args []string) int {
+	isTerminal := false
+	width := defaultFileWidth
+	if f, ok := c.outStream.(*os.File); ok {
+		fd := int(f.Fd())
+		isTerminal = terminal.IsTerminal(fd)
+		if w, _, err := terminal.GetSize(fd); err == nil {
+			width = w
+		}
+	}
+
+	var (
+		indent    string
+		colorMode string
+	)
+	flags := flag.NewFlagSet("jpp schema", flag.ContinueOnError)
+	flags.SetOutput(c.errStream)
+	flags.IntVar(&width, "w", width, "width")
+	flags.StringVar(&indent, "i", "  ", "indentation")
+	flags.StringVar(&colorMode, "color", colorAuto, "when to color the output: auto, always or never")
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+	useColor, err := shouldColor(colorMode, isTerminal)
+	if err != nil {
+		fmt.Fprintln(c.errStream, err.Error())
+		return 1
+	}
+
+	var samples []string
+	if flags.NArg() == 0 {
+		src, err := ioutil.ReadAll(c.inStream)
+		if err != nil {
+			fmt.Fprintln(c.errStream, err.Error())
+			return 1
+		}
+		samples = append(samples, string(src))
+	}
+	for _, filename := range flags.Args() {
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			fmt.Fprintln(c.errStream, err.Error())
+			return 1
+		}
+		samples = append(samples, string(src))
+	}
+
+	root := &schemaNode{}
+	for i, sample := range samples {
+		if !gjson.Valid(sample) {
+			name := stdinName
+			if flags.NArg() > 0 {
+				name = flags.Arg(i)
+			}
+			fmt.Fprintf(c.errStream, "%v: parse error: Invalid json input\n", name)
+			return 1
+		}
+		root.merge(gjson.Parse(sample))
+	}
+
+	var b bytes.Buffer
+	root.write(&b, true)
+	opts := jpp.Options{Indent: indent, Width: width, Styler: monochrome}
+	if useColor {
+		opts.Styler = defaultCLIScheme()
+	}
+	res, err := jpp.Format(b.String(), opts)
+	if err != nil {
+		fmt.Fprintln(c.errStream, err.Error())
+		return 1
+	}
+	fmt.Fprintln(c.outStream, res)
+	return 0
+}
+
+// schemaNode accumulates the values at the same location of the samples.
+type schemaNode struct {
+	count int
+	types map[string]bool
+
+	// strs are the distinct strings, or nil if there are more than maxEnum.
+	strs     map[string]bool
+	strCount int
+	tooMany  bool
+
+	hasNum   bool
+	min, max float64
+
+	objects int
+	keys    []string
+	props   map[string]*schemaNode
+
+	items *schemaNode
+}
+
+func (n *schemaNode) merge(j gjson.Result) {
+	n.count++
+	if n.types == nil {
+		n.types = map[string]bool{}
+	}
+	switch j.Type {
+	case gjson.Null:
+		n.types["null"] = true
+	case gjson.True, gjson.False:
+		n.types["boolean"] = true
+	case gjson.Number:
+		if strings.ContainsAny(j.Raw, ".eE") {
+			n.types["number"] = true
+		} else {
+			n.types["integer"] = true
+		}
+		if !n.hasNum || j.Num < n.min {
+			n.min = j.Num
+		}
+		if !n.hasNum || j.Num > n.max {
+			n.max = j.Num
+		}
+		n.hasNum = true
+	case gjson.String:
+		n.types["string"] = true
+		n.strCount++
+		if !n.tooMany {
+			if n.strs == nil {
+				n.strs = map[string]bool{}
+			}
+			n.strs[j.Str] = true
+			if len(n.strs) > maxEnum {
+				n.strs = nil
+				n.tooMany = true
+			}
+		}
+	case gjson.JSON:
+		if j.IsArray() {
+			n.types["array"] = true
+			for _, item := range j.Array() {
+				if n.items == nil {
+					n.items = &schemaNode{}
+				}
+				n.items.merge(item)
+			}
+			return
+		}
+		n.types["object"] = true
+		n.objects++
+		if n.props == nil {
+			n.props = map[string]*schemaNode{}
+		}
+		j.ForEach(func(k, v gjson.Result) bool {
+			prop, ok := n.props[k.Str]
+			if !ok {
+				prop = &schemaNode{}
+				n.keys = append(n.keys, k.Str)
+				n.props[k.Str] = prop
+			}
+			prop.merge(v)
+			return true
+		})
+	}
+}
+
+// typeOrder is the order of the types in the schema.
+var typeOrder = []string{"object", "array", "string", "integer", "number", "boolean", "null"}
+
+// write writes the schema of n as compact JSON.
+// Keys present in all objects are required. Strings are inferred as an enum
+// if there are a few distinct ones and some of them are repeated.
+func (n *schemaNode) write(b *bytes.Buffer, root bool) {
+	var fields []string
+	if root {
+		fields = append(fields, field("$schema", encode(schemaDraft)))
+	}
+	if n.types["integer"] && n.types["number"] {
+		// Integers are numbers.
+		delete(n.types, "integer")
+	}
+	var types []string
+	for _, t := range typeOrder {
+		if n.types[t] {
+			types = append(types, t)
+		}
+	}
+	switch len(types) {
+	case 0:
+	case 1:
+		fields = append(fields, field("type", encode(types[0])))
+	default:
+		fields = append(fields, field("type", encode(types)))
+	}
+	if len(types) == 1 && types[0] == "string" && n.strs != nil && len(n.strs) < n.strCount {
+		var enum []string
+		for s := range n.strs {
+			enum = append(enum, s)
+		}
+		sort.Strings(enum)
+		fields = append(fields, field("enum", encode(enum)))
+	}
+	if n.hasNum {
+		fields = append(fields, field("minimum", encode(n.min)), field("maximum", encode(n.max)))
+	}
+	if n.props != nil {
+		var props bytes.Buffer
+		props.WriteString("{")
+		var required []string
+		for i, key := range n.keys {
+			if i > 0 {
+				props.WriteString(",")
+			}
+			props.WriteString(encode(key))
+			props.WriteString(":")
+			n.props[key].write(&props, false)
+			if n.props[key].count == n.objects {
+				required = append(required, key)
+			}
+		}
+		props.WriteString("}")
+		fields = append(fields, field("properties", props.String()))
+		if len(required) > 0 {
+			fields = append(fields, field("required", encode(required)))
+		}
+	}
+	if n.items != nil {
+		var items bytes.Buffer
+		n.items.write(&items, false)
+		fields = append(fields, field("items", items.String()))
+	}
+	b.WriteString("{" + strings.Join(fields, ",") + "}")
+}
+
+func field(key, value string) string {
+	return encode(key) + ":" + value
+}
+
+func encode(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
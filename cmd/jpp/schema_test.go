@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestSchemaNode(t *testing.T) {
+	samples := []string{
+		`{"id": 1, "status": "active", "tags": ["a"], "score": 1.5}`,
+		`{"id": 7, "status": "active", "email": null, "tags": [], "score": 3}`,
+		`{"id": 3, "status": "inactive", "email": "x@example.com", "tags": ["b"], "score": 2}`,
+	}
+	root := &schemaNode{}
+	for _, sample := range samples {
+		root.merge(gjson.Parse(sample))
+	}
+	var b bytes.Buffer
+	root.write(&b, true)
+	expected := `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{` +
+		`"id":{"type":"integer","minimum":1,"maximum":7},` +
+		`"status":{"type":"string","enum":["active","inactive"]},` +
+		`"tags":{"type":"array","items":{"type":"string"}},` +
+		`"score":{"type":"number","minimum":1.5,"maximum":3},` +
+		`"email":{"type":["string","null"]}},` +
+		`"required":["id","status","tags","score"]}`
+	if b.String() != expected {
+		t.Errorf("got\n%v\nwant\n%v", b.String(), expected)
+	}
+}
+
+func TestRun_schema(t *testing.T) {
+	dir := setupTree(t, map[string]string{
+		"a.json": `[{"name": "a"}]`,
+		"b.json": `[{"name": "b", "age": 20}]`,
+	})
+	defer os.RemoveAll(dir)
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{inStream: strings.NewReader(""), outStream: outStream, errStream: errStream}
+	args := []string{"jpp", "schema", "-w", "60", filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")}
+	if status := c.run(args); status != 0 {
+		t.Fatalf("status = %v: %v", status, errStream.String())
+	}
+	expected := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "array",
+  "items": {
+    "type": "object",
+    "properties": {
+      "name": {"type": "string"},
+      "age": {"type": "integer", "minimum": 20, "maximum": 20}
+    },
+    "required": ["name"]
+  }
+}
+`
+	if outStream.String() != expected {
+		t.Errorf("actual=%v, expected: %v", outStream.String(), expected)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/tanishiking/jpp"
+	"github.com/tidwall/gjson"
+)
+
+// runStats runs `jpp stats`, which reports the statistics of a document
+// to find out what makes it large.
+func (c *cli) runStats(args []string) int {
+	var top int
+	flags := flag.NewFlagSet("jpp stats", flag.ContinueOnError)
+	flags.SetOutput(c.errStream)
+	flags.IntVar(&top, "n", 10, "number of the paths listed in each section")
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+	if flags.NArg() > 1 {
+		fmt.Fprintln(c.errStream, "usage: jpp stats [-n N] [file]")
+		return 1
+	}
+	var src []byte
+	var err error
+	if flags.NArg() == 0 {
+		src, err = ioutil.ReadAll(c.inStream)
+	} else {
+		src, err = ioutil.ReadFile(flags.Arg(0))
+	}
+	if err != nil {
+		fmt.Fprintln(c.errStream, err.Error())
+		return 1
+	}
+	if !gjson.ValidBytes(src) {
+		fmt.Fprintln(c.errStream, "parse error: Invalid json input")
+		return 1
+	}
+	s := newStats(top)
+	root := gjson.ParseBytes(src)
+	root.Raw = strings.TrimSpace(root.Raw)
+	s.walk(jpp.Path{}, root)
+	s.write(c.outStream)
+	return 0
+}
+
+// rankedPath is a path ranked by a number, such as the size of the value.
+type rankedPath struct {
+	path jpp.Path
+	n    int
+}
+
+// ranking keeps the top paths ordered by their numbers, and then by the
+// depths so that parents precede children of the same size.
+type ranking struct {
+	limit int
+	paths []rankedPath
+}
+
+func (r *ranking) add(path jpp.Path, n int) {
+	i := sort.Search(len(r.paths), func(i int) bool {
+		p := r.paths[i]
+		return p.n < n || (p.n == n && len(p.path) > len(path))
+	})
+	if i >= r.limit {
+		return
+	}
+	r.paths = append(r.paths, rankedPath{})
+	copy(r.paths[i+1:], r.paths[i:])
+	r.paths[i] = rankedPath{path: path, n: n}
+	if len(r.paths) > r.limit {
+		r.paths = r.paths[:r.limit]
+	}
+}
+
+type stats struct {
+	counts   map[string]int
+	maxDepth int
+	keys     map[string]bool
+	arrays   ranking
+	strs     ranking
+	sizes    ranking
+}
+
+func newStats(top int) *stats {
+	return &stats{
+		counts: map[string]int{},
+		keys:   map[string]bool{},
+		arrays: ranking{limit: top},
+		strs:   ranking{limit: top},
+		sizes:  ranking{limit: top},
+	}
+}
+
+func (s *stats) walk(path jpp.Path, j gjson.Result) {
+	s.counts[typeOf(j)]++
+	if len(path) > s.maxDepth {
+		s.maxDepth = len(path)
+	}
+	s.sizes.add(path, len(j.Raw))
+	switch {
+	case j.IsArray():
+		n := 0
+		j.ForEach(func(_, v gjson.Result) bool {
+			s.walk(path.Append(n), v)
+			n++
+			return true
+		})
+		s.arrays.add(path, n)
+	case j.IsObject():
+		j.ForEach(func(k, v gjson.Result) bool {
+			s.keys[k.Str] = true
+			s.walk(path.Append(k.Str), v)
+			return true
+		})
+	case j.Type == gjson.String:
+		s.strs.add(path, len([]rune(j.Str)))
+	}
+}
+
+func (s *stats) write(w io.Writer) {
+	total := 0
+	for _, n := range s.counts {
+		total += n
+	}
+	fmt.Fprintf(w, "Values: %v\n", total)
+	for _, t := range []string{"object", "array", "string", "number", "boolean", "null"} {
+		if s.counts[t] > 0 {
+			fmt.Fprintf(w, "  %-8v %v\n", t, s.counts[t])
+		}
+	}
+	fmt.Fprintf(w, "Max depth: %v\n", s.maxDepth)
+	fmt.Fprintf(w, "Distinct keys: %v\n", len(s.keys))
+
+	if len(s.arrays.paths) > 0 {
+		fmt.Fprintln(w, "\nLargest arrays (elements):")
+		writeRanking(w, s.arrays.paths, func(n int) string { return fmt.Sprint(n) })
+	}
+	if len(s.strs.paths) > 0 {
+		fmt.Fprintln(w, "\nLongest strings (characters):")
+		writeRanking(w, s.strs.paths, func(n int) string { return fmt.Sprint(n) })
+	}
+
+	if len(s.sizes.paths) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nSize by subtree (bytes):")
+	// The top paths form a tree since parents are never smaller than their children.
+	rootSize := s.sizes.paths[0].n
+	for _, p := range treeOrder(s.sizes.paths) {
+		percent := 100.0
+		if rootSize > 0 {
+			percent = 100 * float64(p.n) / float64(rootSize)
+		}
+		fmt.Fprintf(w, "  %6v %5.1f%%  %v%v\n", humanSize(p.n), percent, strings.Repeat("  ", len(p.path)), p.path)
+	}
+}
+
+func writeRanking(w io.Writer, paths []rankedPath, format func(int) string) {
+	width := 0
+	for _, p := range paths {
+		if l := len(format(p.n)); l > width {
+			width = l
+		}
+	}
+	for _, p := range paths {
+		fmt.Fprintf(w, "  %*v  %v\n", width, format(p.n), p.path)
+	}
+}
+
+// treeOrder orders the paths depth-first, where siblings are ordered by
+// their sizes like in paths.
+func treeOrder(paths []rankedPath) []rankedPath {
+	children := map[string][]rankedPath{}
+	var roots []rankedPath
+	for _, p := range paths {
+		if len(p.path) == 0 {
+			roots = append(roots, p)
+			continue
+		}
+		parent := p.path[:len(p.path)-1].String()
+		children[parent] = append(children[parent], p)
+	}
+	var ordered []rankedPath
+	var visit func(p rankedPath)
+	visit = func(p rankedPath) {
+		ordered = append(ordered, p)
+		for _, child := range children[p.path.String()] {
+			visit(child)
+		}
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+	return ordered
+}
+
+// humanSize formats the number of bytes like `du -h`.
+func humanSize(n int) string {
+	const units = "KMGTPE"
+	if n < 1024 {
+		return fmt.Sprintf("%vB", n)
+	}
+	size := float64(n)
+	i := -1
+	for size >= 1024 && i < len(units)-1 {
+		size /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.1f%c", size, units[i])
+}
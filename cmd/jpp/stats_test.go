@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRun_stats(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{
+		inStream:  strings.NewReader(` {"a": [1, 2, 3], "b": {"c": "hello", "d": [null, true]}, "e": "hi"}` + "\n"),
+		outStream: outStream,
+		errStream: errStream,
+	}
+	if status := c.run([]string{"jpp", "stats", "-n", "3"}); status != 0 {
+		t.Fatalf("status = %v: %v", status, errStream.String())
+	}
+	expected := `Values: 11
+  object   2
+  array    2
+  string   2
+  number   3
+  boolean  1
+  null     1
+Max depth: 3
+Distinct keys: 5
+
+Largest arrays (elements):
+  3  $.a
+  2  $.b.d
+
+Longest strings (characters):
+  5  $.b.c
+  2  $.e
+
+Size by subtree (bytes):
+     67B 100.0%  $
+     33B  49.3%    $.b
+     12B  17.9%      $.b.d
+`
+	if outStream.String() != expected {
+		t.Errorf("actual=%v, expected: %v", outStream.String(), expected)
+	}
+}
+
+func TestRanking(t *testing.T) {
+	r := ranking{limit: 2}
+	r.add(nil, 1)
+	r.add(nil, 3)
+	r.add(nil, 2)
+	r.add(nil, 0)
+	if len(r.paths) != 2 || r.paths[0].n != 3 || r.paths[1].n != 2 {
+		t.Errorf("got %v, want the top 2", r.paths)
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+	tests := map[int]string{0: "0B", 1023: "1023B", 1024: "1.0K", 1536: "1.5K", 40 << 20: "40.0M"}
+	for n, expected := range tests {
+		if actual := humanSize(n); actual != expected {
+			t.Errorf("humanSize(%v) = %v, want %v", n, actual, expected)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/tanishiking/jpp"
+	"github.com/tidwall/gjson"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// runDiff runs `jpp diff`, which shows the structural differences between
+// two documents. Like diff(1), it exits with 0 if they are the same,
+// 1 if they differ, and 2 on errors, so that it can be used in CI.
+func (c *cli) runDiff(args []string) int {
+	isTerminal := false
+	width := defaultFileWidth
+	if f, ok := c.outStream.(*os.File); ok {
+		fd := int(f.Fd())
+		isTerminal = terminal.IsTerminal(fd)
+		if w, _, err := terminal.GetSize(fd); err == nil {
+			width = w
+		}
+	}
+
+	var (
+		indent    string
+		colorMode string
+		diffOpts  jpp.DiffOptions
+		redact    redactFlags
+	)
+	flags := flag.NewFlagSet("jpp diff", flag.ContinueOnError)
+	flags.SetOutput(c.errStream)
+	flags.IntVar(&width, "w", width, "width")
+	flags.StringVar(&indent, "i", "  ", "indentation")
+	flags.StringVar(&colorMode, "color", colorAuto, "when to color the output: auto, always or never")
+	flags.BoolVar(&diffOpts.IgnoreKeyOrder, "ignore-key-order", false, "don't report objects whose keys are only reordered")
+	flags.StringVar(&diffOpts.IDKey, "id", "", "match the objects in arrays by the value of this key")
+	redact.register(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 2 {
+		fmt.Fprintln(c.errStream, "usage: jpp diff [flags] a.json b.json")
+		return 2
+	}
+	useColor, err := shouldColor(colorMode, isTerminal)
+	if err != nil {
+		fmt.Fprintln(c.errStream, err.Error())
+		return 2
+	}
+	redaction, err := redact.redaction()
+	if err != nil {
+		fmt.Fprintln(c.errStream, err.Error())
+		return 2
+	}
+
+	var srcs [2]string
+	for i, filename := range flags.Args() {
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			fmt.Fprintln(c.errStream, err.Error())
+			return 2
+		}
+		if !gjson.ValidBytes(src) {
+			fmt.Fprintf(c.errStream, "%v: parse error: Invalid json input\n", filename)
+			return 2
+		}
+		srcs[i] = string(src)
+	}
+
+	opts := jpp.Options{Indent: indent, Width: width, Styler: monochrome, Redaction: redaction}
+	if useColor {
+		opts.Styler = defaultCLIScheme()
+		diffOpts.Added = jpp.SGR("32")
+		diffOpts.Removed = jpp.SGR("31")
+		diffOpts.Changed = jpp.SGR("33")
+	}
+	res, differs, err := jpp.Diff(srcs[0], srcs[1], opts, diffOpts)
+	if err != nil {
+		fmt.Fprintln(c.errStream, err.Error())
+		return 2
+	}
+	if !differs {
+		return 0
+	}
+	fmt.Fprintln(c.outStream, res)
+	return 1
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRun_structDiff(t *testing.T) {
+	dir := setupTree(t, map[string]string{
+		"a.json": `{"id": 1, "items": [{"id": "x", "n": 1}, {"id": "y", "n": 2}]}`,
+		"b.json": `{"id": 1, "items": [{"id": "y", "n": 3}, {"id": "x", "n": 1}]}`,
+	})
+	tests := []struct {
+		args     []string
+		status   int
+		expected string
+	}{
+		{[]string{"--id", "id", "a.json", "b.json"}, 1, `  {
+    … 1 unchanged member
+    "items": [
+-     {"id": "x", "n": 1}
+      {
+        … 1 unchanged member
+~       "n": 2 → 3
+      }
++     {"id": "x", "n": 1}
+    ]
+  }
+`},
+		{[]string{"a.json", "a.json"}, 0, ""},
+		{[]string{"a.json"}, 2, ""},
+		{[]string{"a.json", "missing.json"}, 2, ""},
+	}
+	for _, test := range tests {
+		outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+		c := &cli{inStream: strings.NewReader(""), outStream: outStream, errStream: errStream}
+		args := []string{"jpp", "diff", "--color", "never"}
+		for _, arg := range test.args {
+			if strings.HasSuffix(arg, ".json") {
+				arg = dir + "/" + arg
+			}
+			args = append(args, arg)
+		}
+		if status := c.run(args); status != test.status {
+			t.Errorf("%v: status = %v, want %v: %v", test.args, status, test.status, errStream.String())
+		}
+		if outStream.String() != test.expected {
+			t.Errorf("%v: got\n%v\nwant\n%v", test.args, outStream.String(), test.expected)
+		}
+	}
+}
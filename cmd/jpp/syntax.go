@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// syntaxError is a JSON syntax error at the byte offset.
+type syntaxError struct {
+	offset int
+	msg    string
+}
+
+func (e *syntaxError) Error() string {
+	return fmt.Sprintf("parse error at offset %v: %v", e.offset, e.msg)
+}
+
+// checkSyntax returns the first syntax error in the JSON src, or nil.
+func checkSyntax(src string) *syntaxError {
+	var v json.RawMessage
+	err := json.Unmarshal([]byte(src), &v)
+	if err == nil {
+		return nil
+	}
+	if serr, ok := err.(*json.SyntaxError); ok {
+		// The error occurred after reading Offset bytes.
+		offset := int(serr.Offset) - 1
+		if strings.HasPrefix(serr.Error(), "unexpected end") {
+			offset = len(src)
+		}
+		if offset < 0 {
+			offset = 0
+		}
+		return &syntaxError{offset: offset, msg: serr.Error()}
+	}
+	return &syntaxError{offset: len(src), msg: err.Error()}
+}
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestCheckSyntax(t *testing.T) {
+	tests := []struct {
+		src    string
+		offset int
+	}{
+		{`{"a": 1}`, -1},
+		{`{"a" 1}`, 5},
+		{`[1, 2`, 5},
+	}
+	for _, test := range tests {
+		err := checkSyntax(test.src)
+		offset := -1
+		if err != nil {
+			offset = err.offset
+		}
+		if offset != test.offset {
+			t.Errorf("checkSyntax(%q) at %v, want %v (%v)", test.src, offset, test.offset, err)
+		}
+	}
+}
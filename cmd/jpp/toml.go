@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOML parses the subset of TOML used by .jpp.toml: tables, arrays of
+// tables, and key/value pairs of strings, integers, floats, booleans and
+// arrays. The result can be converted to JSON.
+func parseTOML(src string) (map[string]interface{}, error) {
+	root := map[string]interface{}{}
+	current := root
+	lines := strings.Split(src, "\n")
+	for n := 0; n < len(lines); n++ {
+		line := strings.TrimSpace(stripTOMLComment(lines[n]))
+		if line == "" {
+			continue
+		}
+		lineNum := n + 1
+
+		if strings.HasPrefix(line, "[[") && strings.HasSuffix(line, "]]") {
+			name := strings.TrimSpace(line[2 : len(line)-2])
+			table := map[string]interface{}{}
+			tables, _ := root[name].([]interface{})
+			root[name] = append(tables, table)
+			current = table
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			table, ok := root[name].(map[string]interface{})
+			if !ok {
+				table = map[string]interface{}{}
+				root[name] = table
+			}
+			current = table
+			continue
+		}
+
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("line %v: expected key = value", lineNum)
+		}
+		key := strings.TrimSpace(line[:eq])
+		if unquoted, err := strconv.Unquote(key); err == nil {
+			key = unquoted
+		}
+		value := strings.TrimSpace(line[eq+1:])
+		// Arrays may span multiple lines.
+		for strings.HasPrefix(value, "[") && !tomlBalanced(value) && n+1 < len(lines) {
+			n++
+			value += " " + strings.TrimSpace(stripTOMLComment(lines[n]))
+		}
+		p := &tomlParser{src: value}
+		v, err := p.value()
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %v", lineNum, err)
+		}
+		if p.skipSpaces(); p.pos != len(p.src) {
+			return nil, fmt.Errorf("line %v: unexpected %q", lineNum, p.src[p.pos:])
+		}
+		current[key] = v
+	}
+	return root, nil
+}
+
+// stripTOMLComment removes the comment outside strings from the line.
+func stripTOMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0 && c == '\\' && quote == '"':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// tomlBalanced reports whether the brackets in the value are balanced.
+func tomlBalanced(value string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case quote != 0 && c == '\\' && quote == '"':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '[':
+			depth++
+		case quote == 0 && c == ']':
+			depth--
+		}
+	}
+	return depth == 0
+}
+
+type tomlParser struct {
+	src string
+	pos int
+}
+
+func (p *tomlParser) skipSpaces() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) value() (interface{}, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.src) {
+		return nil, fmt.Errorf("missing value")
+	}
+	switch c := p.src[p.pos]; {
+	case c == '"':
+		return p.basicString()
+	case c == '\'':
+		end := strings.IndexByte(p.src[p.pos+1:], '\'')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated string")
+		}
+		s := p.src[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return s, nil
+	case c == '[':
+		return p.array()
+	default:
+		start := p.pos
+		for p.pos < len(p.src) && !strings.ContainsRune(" \t,]", rune(p.src[p.pos])) {
+			p.pos++
+		}
+		return parseTOMLScalar(p.src[start:p.pos])
+	}
+}
+
+func (p *tomlParser) basicString() (string, error) {
+	for i := p.pos + 1; i < len(p.src); i++ {
+		switch p.src[i] {
+		case '\\':
+			i++
+		case '"':
+			s, err := strconv.Unquote(p.src[p.pos : i+1])
+			if err != nil {
+				return "", err
+			}
+			p.pos = i + 1
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+func (p *tomlParser) array() ([]interface{}, error) {
+	p.pos++ // [
+	arr := []interface{}{}
+	for {
+		p.skipSpaces()
+		if p.pos >= len(p.src) {
+			return nil, fmt.Errorf("unterminated array")
+		}
+		if p.src[p.pos] == ']' {
+			p.pos++
+			return arr, nil
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+		p.skipSpaces()
+		if p.pos < len(p.src) && p.src[p.pos] == ',' {
+			p.pos++
+		}
+	}
+}
+
+func parseTOMLScalar(s string) (interface{}, error) {
+	switch s {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	digits := strings.Replace(s, "_", "", -1)
+	if i, err := strconv.ParseInt(digits, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(digits, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("invalid value %q", s)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	src := `
+# formatter settings
+width = 1_00
+indent = "\t" # tab
+sortKeys = true
+layout = 'compact'
+
+[[overrides]]
+files = [
+  "fixtures/**/*.json", # fixtures
+  'generated/*.json',
+]
+width = 2.5e1
+
+[[overrides]]
+files = ["a#b.json"]
+`
+	actual, err := parseTOML(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"width":    int64(100),
+		"indent":   "\t",
+		"sortKeys": true,
+		"layout":   "compact",
+		"overrides": []interface{}{
+			map[string]interface{}{
+				"files": []interface{}{"fixtures/**/*.json", "generated/*.json"},
+				"width": 25.0,
+			},
+			map[string]interface{}{
+				"files": []interface{}{"a#b.json"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestParseTOML_Invalid(t *testing.T) {
+	for _, src := range []string{"width", "width = ", `indent = "foo`, "files = [1, 2", "width = 80 90"} {
+		if _, err := parseTOML(src); err == nil {
+			t.Errorf("expected %q to be rejected", src)
+		}
+	}
+}
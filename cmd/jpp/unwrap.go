@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/tidwall/gjson"
+)
+
+// unwrap decodes src while it is a JSON string containing JSON, so that
+// escaped JSON copied from logs, even if it's double-encoded, can be read.
+// It returns an error if the top-level string doesn't contain JSON,
+// and src as is if it is not a string.
+func unwrap(src string) (string, error) {
+	if err := checkSyntax(src); err != nil {
+		return "", err
+	}
+	j := gjson.Parse(src)
+	if j.Type != gjson.String {
+		return src, nil
+	}
+	if err := checkSyntax(j.Str); err != nil {
+		return "", fmt.Errorf("--unwrap: the string doesn't contain JSON: %v", err)
+	}
+	for j.Type == gjson.String && checkSyntax(j.Str) == nil {
+		src = j.Str
+		j = gjson.Parse(src)
+	}
+	return src, nil
+}
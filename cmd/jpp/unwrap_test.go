@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestUnwrap(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+		err      string
+	}{
+		{`"{\"id\":1}"`, `{"id":1}`, ""},
+		{`"\"{\\\"id\\\":1}\""`, `{"id":1}`, ""},
+		{`"\"hi\""`, `"hi"`, ""},
+		{` {"a": "[1]"}`, ` {"a": "[1]"}`, ""},
+		{`"{\"id\":}"`, "", "--unwrap: the string doesn't contain JSON: parse error at offset 6: invalid character '}' looking for beginning of value"},
+		{`"hello"`, "", "--unwrap: the string doesn't contain JSON: parse error at offset 0: invalid character 'h' looking for beginning of value"},
+		{`"{`, "", "parse error at offset 2: unexpected end of JSON input"},
+	}
+	for _, test := range tests {
+		actual, err := unwrap(test.src)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%v: got error %v, want %v", test.src, err, test.err)
+			}
+			continue
+		}
+		if err != nil || actual != test.expected {
+			t.Errorf("%v: got %v (%v), want %v", test.src, actual, err, test.expected)
+		}
+	}
+}
+
+func TestRun_unwrap(t *testing.T) {
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{
+		inStream:  strings.NewReader(`"{\"id\":1,\"tags\":[\"a\"]}"` + "\n"),
+		outStream: outStream,
+		errStream: errStream,
+	}
+	if status := c.run(strings.Split("jpp --color never --no-config -w 80 --unwrap", " ")); status != 0 {
+		t.Fatalf("status = %v: %v", status, errStream.String())
+	}
+	expected := `{
+  "id": 1,
+  "tags": ["a"]
+}
+`
+	if outStream.String() != expected {
+		t.Errorf("actual=%v, expected: %v", outStream.String(), expected)
+	}
+}
@@ -0,0 +1,574 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/tanishiking/jpp"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	draft7      = "draft-07"
+	draft202012 = "2020-12"
+)
+
+// violation is a value that doesn't conform to the schema.
+type violation struct {
+	path jpp.Path
+	msg  string
+}
+
+// schemaValidator validates documents against a JSON Schema of draft 7 or 2020-12.
+// $ref is resolved only in the schema itself. Formats, unevaluated* and
+// dynamic references are not checked.
+type schemaValidator struct {
+	root  interface{}
+	draft string
+	// patterns caches the compiled patterns. The validator is shared by
+	// the workers validating files, so mu guards it.
+	mu       sync.Mutex
+	patterns map[string]*regexp.Regexp
+}
+
+// loadSchema reads the schema in the file.
+func loadSchema(filename string) (*schemaValidator, error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var root interface{}
+	if err := json.Unmarshal(src, &root); err != nil {
+		return nil, fmt.Errorf("%v: %v", filename, err)
+	}
+	v := &schemaValidator{root: root, draft: draft202012, patterns: map[string]*regexp.Regexp{}}
+	if obj, ok := root.(map[string]interface{}); ok {
+		if uri, ok := obj["$schema"].(string); ok {
+			switch {
+			case strings.Contains(uri, "draft-07"):
+				v.draft = draft7
+			case strings.Contains(uri, "2020-12"):
+				v.draft = draft202012
+			default:
+				return nil, fmt.Errorf("%v: unsupported $schema %q: must be draft 7 or 2020-12", filename, uri)
+			}
+		}
+	}
+	return v, nil
+}
+
+// validate returns the violations in jsonStr.
+func (v *schemaValidator) validate(jsonStr string) []violation {
+	var vs []violation
+	v.check(v.root, gjson.Parse(jsonStr), jpp.Path{}, &vs)
+	return vs
+}
+
+// valid reports whether j conforms to schema.
+func (v *schemaValidator) valid(schema interface{}, j gjson.Result, path jpp.Path) bool {
+	var vs []violation
+	v.check(schema, j, path, &vs)
+	return len(vs) == 0
+}
+
+func (v *schemaValidator) check(schema interface{}, j gjson.Result, path jpp.Path, vs *[]violation) {
+	report := func(format string, args ...interface{}) {
+		*vs = append(*vs, violation{path: path, msg: fmt.Sprintf(format, args...)})
+	}
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			report("no value is allowed")
+		}
+		return
+	case map[string]interface{}:
+		if ref, ok := s["$ref"].(string); ok {
+			resolved, err := v.resolve(ref)
+			if err != nil {
+				report("%v", err)
+			} else {
+				v.check(resolved, j, path, vs)
+			}
+			// Keywords next to $ref are ignored in draft 7.
+			if v.draft == draft7 {
+				return
+			}
+		}
+		v.checkGeneric(s, j, report)
+		switch {
+		case j.Type == gjson.Number:
+			v.checkNumber(s, j, report)
+		case j.Type == gjson.String:
+			v.checkString(s, j, report)
+		case j.IsArray():
+			v.checkArray(s, j, path, vs, report)
+		case j.IsObject():
+			v.checkObject(s, j, path, vs, report)
+		}
+		v.checkCombinators(s, j, path, vs, report)
+	}
+}
+
+func typeOf(j gjson.Result) string {
+	switch j.Type {
+	case gjson.Null:
+		return "null"
+	case gjson.True, gjson.False:
+		return "boolean"
+	case gjson.Number:
+		return "number"
+	case gjson.String:
+		return "string"
+	default:
+		if j.IsArray() {
+			return "array"
+		}
+		return "object"
+	}
+}
+
+func hasType(j gjson.Result, t string) bool {
+	actual := typeOf(j)
+	if t == "integer" {
+		return actual == "number" && j.Num == math.Trunc(j.Num)
+	}
+	return actual == t
+}
+
+func (v *schemaValidator) checkGeneric(s map[string]interface{}, j gjson.Result, report func(string, ...interface{})) {
+	if t, ok := s["type"]; ok {
+		var types []string
+		switch t := t.(type) {
+		case string:
+			types = []string{t}
+		case []interface{}:
+			for _, e := range t {
+				if e, ok := e.(string); ok {
+					types = append(types, e)
+				}
+			}
+		}
+		matched := false
+		for _, t := range types {
+			if hasType(j, t) {
+				matched = true
+			}
+		}
+		if !matched {
+			report("expected %v, got %v", strings.Join(types, " or "), typeOf(j))
+		}
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		matched := false
+		for _, e := range enum {
+			if jsonEqual(j.Value(), e) {
+				matched = true
+			}
+		}
+		if !matched {
+			report("must be one of %v", encodeAll(enum))
+		}
+	}
+	if c, ok := s["const"]; ok && !jsonEqual(j.Value(), c) {
+		report("must be %v", encode(c))
+	}
+}
+
+func (v *schemaValidator) checkNumber(s map[string]interface{}, j gjson.Result, report func(string, ...interface{})) {
+	if min, ok := s["minimum"].(float64); ok && j.Num < min {
+		report("must be >= %v", encode(min))
+	}
+	if max, ok := s["maximum"].(float64); ok && j.Num > max {
+		report("must be <= %v", encode(max))
+	}
+	if min, ok := s["exclusiveMinimum"].(float64); ok && j.Num <= min {
+		report("must be > %v", encode(min))
+	}
+	if max, ok := s["exclusiveMaximum"].(float64); ok && j.Num >= max {
+		report("must be < %v", encode(max))
+	}
+	if m, ok := s["multipleOf"].(float64); ok && m > 0 {
+		q := j.Num / m
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			report("must be a multiple of %v", encode(m))
+		}
+	}
+}
+
+func (v *schemaValidator) checkString(s map[string]interface{}, j gjson.Result, report func(string, ...interface{})) {
+	length := float64(utf8.RuneCountInString(j.Str))
+	if min, ok := s["minLength"].(float64); ok && length < min {
+		report("length must be >= %v", min)
+	}
+	if max, ok := s["maxLength"].(float64); ok && length > max {
+		report("length must be <= %v", max)
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		re, err := v.regexp(pattern)
+		if err != nil {
+			report("invalid pattern %q in the schema", pattern)
+		} else if !re.MatchString(j.Str) {
+			report("must match %v", pattern)
+		}
+	}
+}
+
+func (v *schemaValidator) checkArray(s map[string]interface{}, j gjson.Result, path jpp.Path, vs *[]violation, report func(string, ...interface{})) {
+	items := j.Array()
+	n := float64(len(items))
+	if min, ok := s["minItems"].(float64); ok && n < min {
+		report("must have at least %v items", min)
+	}
+	if max, ok := s["maxItems"].(float64); ok && n > max {
+		report("must have at most %v items", max)
+	}
+	if unique, _ := s["uniqueItems"].(bool); unique {
+		for a := range items {
+			for b := a + 1; b < len(items); b++ {
+				if jsonEqual(items[a].Value(), items[b].Value()) {
+					report("items at %v and %v must be unique", a, b)
+				}
+			}
+		}
+	}
+
+	// The schemas of the leading items, and the schema of the rest.
+	var prefix []interface{}
+	rest, hasRest := s["items"], false
+	if v.draft == draft7 {
+		if tuple, ok := rest.([]interface{}); ok {
+			prefix = tuple
+			rest, hasRest = s["additionalItems"]
+		} else {
+			_, hasRest = s["items"]
+		}
+	} else {
+		prefix, _ = s["prefixItems"].([]interface{})
+		_, hasRest = s["items"]
+	}
+	for i, item := range items {
+		if i < len(prefix) {
+			v.check(prefix[i], item, path.Append(i), vs)
+		} else if hasRest {
+			v.check(rest, item, path.Append(i), vs)
+		}
+	}
+
+	if contains, ok := s["contains"]; ok {
+		count := 0
+		for i, item := range items {
+			if v.valid(contains, item, path.Append(i)) {
+				count++
+			}
+		}
+		min := 1.0
+		if m, ok := s["minContains"].(float64); ok {
+			min = m
+		}
+		if float64(count) < min {
+			report("must contain at least %v matching items", min)
+		}
+		if max, ok := s["maxContains"].(float64); ok && float64(count) > max {
+			report("must contain at most %v matching items", max)
+		}
+	}
+}
+
+func (v *schemaValidator) checkObject(s map[string]interface{}, j gjson.Result, path jpp.Path, vs *[]violation, report func(string, ...interface{})) {
+	var keys []gjson.Result
+	present := map[string]bool{}
+	j.ForEach(func(k, _ gjson.Result) bool {
+		keys = append(keys, k)
+		present[k.Str] = true
+		return true
+	})
+	n := float64(len(keys))
+	if min, ok := s["minProperties"].(float64); ok && n < min {
+		report("must have at least %v properties", min)
+	}
+	if max, ok := s["maxProperties"].(float64); ok && n > max {
+		report("must have at most %v properties", max)
+	}
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, r := range required {
+			if r, ok := r.(string); ok && !present[r] {
+				report("missing required property %v", encode(r))
+			}
+		}
+	}
+	dependentRequired, _ := s["dependentRequired"].(map[string]interface{})
+	dependentSchemas, _ := s["dependentSchemas"].(map[string]interface{})
+	if v.draft == draft7 {
+		// dependencies is split into dependentRequired and dependentSchemas in 2019-09.
+		dependentRequired = map[string]interface{}{}
+		dependentSchemas = map[string]interface{}{}
+		deps, _ := s["dependencies"].(map[string]interface{})
+		for key, dep := range deps {
+			if _, ok := dep.([]interface{}); ok {
+				dependentRequired[key] = dep
+			} else {
+				dependentSchemas[key] = dep
+			}
+		}
+	}
+	for _, k := range keys {
+		if required, ok := dependentRequired[k.Str].([]interface{}); ok {
+			for _, r := range required {
+				if r, ok := r.(string); ok && !present[r] {
+					report("missing property %v required by %v", encode(r), encode(k.Str))
+				}
+			}
+		}
+		if dep, ok := dependentSchemas[k.Str]; ok {
+			v.check(dep, j, path, vs)
+		}
+	}
+
+	properties, _ := s["properties"].(map[string]interface{})
+	patternProperties, _ := s["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := s["additionalProperties"]
+	propertyNames, hasPropertyNames := s["propertyNames"]
+	j.ForEach(func(k, value gjson.Result) bool {
+		valuePath := path.Append(k.Str)
+		if hasPropertyNames {
+			var nameViolations []violation
+			v.check(propertyNames, gjson.Parse(k.Raw), valuePath, &nameViolations)
+			for _, nv := range nameViolations {
+				*vs = append(*vs, violation{path: valuePath, msg: "property name " + nv.msg})
+			}
+		}
+		matched := false
+		if schema, ok := properties[k.Str]; ok {
+			matched = true
+			v.check(schema, value, valuePath, vs)
+		}
+		for _, pattern := range sortedKeys(patternProperties) {
+			re, err := v.regexp(pattern)
+			if err == nil && re.MatchString(k.Str) {
+				matched = true
+				v.check(patternProperties[pattern], value, valuePath, vs)
+			}
+		}
+		if !matched && hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				*vs = append(*vs, violation{path: valuePath, msg: "property is not allowed"})
+			} else {
+				v.check(additional, value, valuePath, vs)
+			}
+		}
+		return true
+	})
+}
+
+func (v *schemaValidator) checkCombinators(s map[string]interface{}, j gjson.Result, path jpp.Path, vs *[]violation, report func(string, ...interface{})) {
+	if all, ok := s["allOf"].([]interface{}); ok {
+		for _, schema := range all {
+			v.check(schema, j, path, vs)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, schema := range anyOf {
+			if v.valid(schema, j, path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			report("must match at least one schema of anyOf")
+		}
+	}
+	if one, ok := s["oneOf"].([]interface{}); ok {
+		count := 0
+		for _, schema := range one {
+			if v.valid(schema, j, path) {
+				count++
+			}
+		}
+		if count != 1 {
+			report("must match exactly one schema of oneOf, but matches %v", count)
+		}
+	}
+	if not, ok := s["not"]; ok && v.valid(not, j, path) {
+		report("must not match the schema of not")
+	}
+	if cond, ok := s["if"]; ok {
+		if v.valid(cond, j, path) {
+			if then, ok := s["then"]; ok {
+				v.check(then, j, path, vs)
+			}
+		} else if els, ok := s["else"]; ok {
+			v.check(els, j, path, vs)
+		}
+	}
+}
+
+func (v *schemaValidator) regexp(pattern string) (*regexp.Regexp, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if re, ok := v.patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	v.patterns[pattern] = re
+	return re, nil
+}
+
+// resolve resolves the reference in the schema itself,
+// which is a JSON Pointer such as "#/$defs/item" or an anchor such as "#item".
+func (v *schemaValidator) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported $ref %q: only references in the schema are supported", ref)
+	}
+	fragment, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid $ref %q", ref)
+	}
+	if fragment != "" && !strings.HasPrefix(fragment, "/") {
+		if s := findAnchor(v.root, fragment); s != nil {
+			return s, nil
+		}
+		return nil, fmt.Errorf("unresolved $ref %q", ref)
+	}
+	s := v.root
+	for _, token := range strings.Split(fragment, "/")[1:] {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		switch parent := s.(type) {
+		case map[string]interface{}:
+			child, ok := parent[token]
+			if !ok {
+				return nil, fmt.Errorf("unresolved $ref %q", ref)
+			}
+			s = child
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(parent) {
+				return nil, fmt.Errorf("unresolved $ref %q", ref)
+			}
+			s = parent[i]
+		default:
+			return nil, fmt.Errorf("unresolved $ref %q", ref)
+		}
+	}
+	return s, nil
+}
+
+// findAnchor finds the subschema whose $anchor is name, or whose $id is "#name" in draft 7.
+func findAnchor(schema interface{}, name string) interface{} {
+	switch s := schema.(type) {
+	case map[string]interface{}:
+		if s["$anchor"] == name || s["$id"] == "#"+name {
+			return s
+		}
+		for _, key := range sortedKeys(s) {
+			if found := findAnchor(s[key], name); found != nil {
+				return found
+			}
+		}
+	case []interface{}:
+		for _, e := range s {
+			if found := findAnchor(e, name); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// jsonEqual reports whether the decoded JSON values are equal.
+func jsonEqual(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+func encodeAll(vs []interface{}) string {
+	strs := make([]string, len(vs))
+	for i, v := range vs {
+		strs[i] = encode(v)
+	}
+	return strings.Join(strs, ", ")
+}
+
+// highlightStyler highlights the tokens of the values that violate the schema.
+// Of the punctuation of containers, only the brackets are highlighted.
+type highlightStyler struct {
+	base  jpp.Styler
+	paths map[string]bool
+}
+
+var highlight = jpp.SGR("1;4;31")
+
+func (s highlightStyler) Style(kind jpp.TokenKind, path jpp.Path, text string) (string, int) {
+	if s.paths[path.String()] && (kind != jpp.PunctuationToken || strings.ContainsAny(text, "[]{}")) {
+		return highlight("%s", text), len([]rune(text))
+	}
+	return s.base.Style(kind, path, text)
+}
+
+// annotate appends the messages of the violations to the lines of res
+// where their values start.
+func annotate(res string, mappings []jpp.Mapping, vs []violation, color jpp.ColoredFormat) string {
+	lines := strings.Split(res, "\n")
+	// starts maps the path of each value to the line of its first token.
+	starts := map[string]int{}
+	line, pos := 0, 0
+	for _, m := range mappings {
+		for ; pos < m.Output.Start; pos++ {
+			if res[pos] == '\n' {
+				line++
+			}
+		}
+		p := m.Path.String()
+		if _, ok := starts[p]; !ok {
+			starts[p] = line
+		}
+	}
+	notes := make([][]string, len(lines))
+	for _, v := range vs {
+		if line, ok := starts[v.path.String()]; ok {
+			notes[line] = append(notes[line], v.msg)
+		}
+	}
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(line)
+		if len(notes[i]) > 0 {
+			b.WriteString(color("%s", "  // "+strings.Join(notes[i], "; ")))
+		}
+	}
+	return b.String()
+}
+
+// violationList lists the paths and the messages of the violations.
+func violationList(vs []violation, color jpp.ColoredFormat) string {
+	var b strings.Builder
+	for _, v := range vs {
+		b.WriteString("\n")
+		b.WriteString(color("%s", v.path.String()+": "+v.msg))
+	}
+	return b.String()
+}
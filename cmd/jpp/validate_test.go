@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSchemaValidator(t *testing.T) {
+	tests := []struct {
+		schema   string
+		doc      string
+		expected []string
+	}{
+		{
+			`{"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}, "additionalProperties": false}`,
+			`{"id": 1.5, "x": null}`,
+			[]string{"$.id: expected integer, got number", "$.x: property is not allowed"},
+		},
+		{
+			`{"type": "object", "required": ["id"]}`,
+			`[]`,
+			[]string{"$: expected object, got array"},
+		},
+		{
+			`{"$schema": "http://json-schema.org/draft-07/schema#", "items": [{"type": "string"}], "additionalItems": {"$ref": "#/definitions/n"},
+			  "definitions": {"n": {"type": "number", "exclusiveMaximum": 10, "multipleOf": 2}}}`,
+			`["a", 2, 3, 10]`,
+			[]string{"$[2]: must be a multiple of 2", "$[3]: must be < 10"},
+		},
+		{
+			`{"prefixItems": [{"const": "a"}], "items": {"minLength": 2, "pattern": "^x"}, "uniqueItems": true, "contains": {"const": "xyz"}}`,
+			`["b", "xy", "xy"]`,
+			[]string{"$: items at 1 and 2 must be unique", `$[0]: must be "a"`, "$: must contain at least 1 matching items"},
+		},
+		{
+			`{"anyOf": [{"type": "string"}, {"type": "number"}], "oneOf": [{"minimum": 0}, {"maximum": 10}], "not": {"const": 5}}`,
+			`5`,
+			[]string{"$: must match exactly one schema of oneOf, but matches 2", "$: must not match the schema of not"},
+		},
+		{
+			`{"if": {"properties": {"kind": {"const": "a"}}}, "then": {"required": ["a"]}, "else": {"required": ["b"]},
+			  "dependentRequired": {"a": ["c"]}, "propertyNames": {"maxLength": 4}, "patternProperties": {"^n": {"type": "null"}}}`,
+			`{"kind": "a", "a": 1, "names": 0}`,
+			[]string{`$: missing property "c" required by "a"`, "$.names: property name length must be <= 4", "$.names: expected null, got number"},
+		},
+		{`{"$ref": "#item", "$defs": {"i": {"$anchor": "item", "type": "boolean"}}}`, `true`, nil},
+		{`false`, `1`, []string{"$: no value is allowed"}},
+	}
+	dir, err := ioutil.TempDir("", "jpp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for i, test := range tests {
+		filename := filepath.Join(dir, "schema.json")
+		if err := ioutil.WriteFile(filename, []byte(test.schema), 0644); err != nil {
+			t.Fatal(err)
+		}
+		v, err := loadSchema(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var actual []string
+		for _, v := range v.validate(test.doc) {
+			actual = append(actual, v.path.String()+": "+v.msg)
+		}
+		if strings.Join(actual, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("%v: got\n%v\nwant\n%v", i, strings.Join(actual, "\n"), strings.Join(test.expected, "\n"))
+		}
+	}
+}
+
+func TestRun_schema_validation(t *testing.T) {
+	dir := setupTree(t, map[string]string{
+		"schema.json": `{"properties": {"id": {"type": "integer"}}, "required": ["id", "name"]}`,
+	})
+	defer os.RemoveAll(dir)
+	outStream, errStream := new(bytes.Buffer), new(bytes.Buffer)
+	c := &cli{
+		inStream:  strings.NewReader(`{"id": "1"}`),
+		outStream: outStream,
+		errStream: errStream,
+	}
+	args := []string{"jpp", "-w", "40", "--color", "never", "--schema", filepath.Join(dir, "schema.json")}
+	if status := c.run(args); status != 1 {
+		t.Errorf("status = %v, want 1", status)
+	}
+	expected := `{"id": "1"}  // missing required property "name"; expected integer, got string
+
+$: missing required property "name"
+$.id: expected integer, got string
+`
+	if outStream.String() != expected {
+		t.Errorf("actual=%v, expected: %v", outStream.String(), expected)
+	}
+
+	outStream.Reset()
+	c.inStream = strings.NewReader(`{"id": 1, "name": "a"}`)
+	if status := c.run(args); status != 0 {
+		t.Errorf("status = %v for a valid document, want 0: %v", status, errStream.String())
+	}
+}
+
+func TestSchemaValidator_concurrent(t *testing.T) {
+	dir := setupTree(t, map[string]string{
+		"schema.json": `{"items": {"pattern": "^a"}, "propertyNames": {"pattern": "^[a-z]+$"}}`,
+	})
+	defer os.RemoveAll(dir)
+	v, err := loadSchema(filepath.Join(dir, "schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The workers of processFiles share the validator.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if vs := v.validate(`["ab", "b"]`); len(vs) != 1 {
+				t.Errorf("got %v, want one violation", vs)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// walkOptions specifies which files are formatted in directories.
+type walkOptions struct {
+	// exts is the extensions of the files, such as ".json".
+	exts []string
+	// includes and excludes are glob patterns matched against the path
+	// relative to the directory, or the name if the pattern has no slash.
+	includes []string
+	excludes []string
+	// gitignore skips the files ignored by .gitignore files.
+	gitignore bool
+}
+
+// collectFiles expands the arguments into the files to format.
+// A directory, or a path followed by "/..." like Go packages, is walked
+// recursively in lexical order. Files given explicitly are always included.
+func collectFiles(args []string, opts walkOptions) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		root := arg
+		if strings.HasSuffix(arg, "/...") {
+			root = strings.TrimSuffix(arg, "/...")
+			if root == "" {
+				root = "/"
+			}
+		}
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, root)
+			continue
+		}
+		found, err := walkDir(root, opts)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, found...)
+	}
+	return files, nil
+}
+
+func walkDir(root string, opts walkOptions) ([]string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	// ignores is the .gitignore files that apply to each absolute directory.
+	var ignores map[string]ignoreList
+	if opts.gitignore {
+		parents, err := parentGitignores(absRoot)
+		if err != nil {
+			return nil, err
+		}
+		ignores = map[string]ignoreList{filepath.Dir(absRoot): parents}
+	}
+
+	var files []string
+	err = filepath.Walk(root, func(filename string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, filename)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		abs := filepath.Join(absRoot, rel)
+
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if rel != "." && matchAny(opts.excludes, rel) {
+				return filepath.SkipDir
+			}
+			if ignores != nil {
+				list := ignores[filepath.Dir(abs)]
+				if rel != "." && list.ignored(abs, true) {
+					return filepath.SkipDir
+				}
+				g, err := loadGitignore(abs)
+				if err != nil {
+					return err
+				}
+				if g != nil {
+					list = append(list[:len(list):len(list)], g)
+				}
+				ignores[abs] = list
+			}
+			return nil
+		}
+
+		if !hasExt(filename, opts.exts) {
+			return nil
+		}
+		if len(opts.includes) > 0 && !matchAny(opts.includes, rel) {
+			return nil
+		}
+		if matchAny(opts.excludes, rel) {
+			return nil
+		}
+		if ignores != nil && ignores[filepath.Dir(abs)].ignored(abs, false) {
+			return nil
+		}
+		files = append(files, filename)
+		return nil
+	})
+	return files, err
+}
+
+// parentGitignores loads the .gitignore files in the parent directories of
+// the absolute path root up to the root of the git repository.
+// It returns nothing if root is not in a git repository.
+func parentGitignores(root string) (ignoreList, error) {
+	var dirs []string
+	dir := filepath.Dir(root)
+	for {
+		dirs = append(dirs, dir)
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			// root is not in a git repository.
+			return nil, nil
+		}
+		dir = parent
+	}
+
+	var list ignoreList
+	for i := len(dirs) - 1; i >= 0; i-- {
+		g, err := loadGitignore(dirs[i])
+		if err != nil {
+			return nil, err
+		}
+		if g != nil {
+			list = append(list, g)
+		}
+	}
+	return list, nil
+}
+
+func hasExt(filename string, exts []string) bool {
+	ext := filepath.Ext(filename)
+	for _, e := range exts {
+		if !strings.HasPrefix(e, ".") {
+			e = "." + e
+		}
+		if strings.EqualFold(ext, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAny reports whether the slash-separated path relative to the root
+// matches any of the patterns. Patterns without slashes match the name.
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if strings.Contains(p, "/") {
+			if matchGlob(strings.TrimPrefix(p, "/"), rel) {
+				return true
+			}
+		} else if matchGlob(p, path.Base(rel)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func setupTree(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "jpp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCollectFiles(t *testing.T) {
+	dir := setupTree(t, map[string]string{
+		".git/config":             "",
+		".git/x.json":             "{}",
+		".gitignore":              "ignored/\n*.gen.json\n",
+		"b.json":                  "{}",
+		"a.JSON":                  "{}",
+		"c.txt":                   "",
+		"x.gen.json":              "{}",
+		"ignored/d.json":          "{}",
+		"sub/.gitignore":          "!*.gen.json\nlocal.json\n",
+		"sub/e.json":              "{}",
+		"sub/y.gen.json":          "{}",
+		"sub/local.json":          "{}",
+		"vendor/f.json":           "{}",
+		"sub/nested/deep/g.json":  "{}",
+		"sub/nested/deep/h.jsonc": "{}",
+	})
+	defer os.RemoveAll(dir)
+	// Pretend dir is a git repository.
+	os.Mkdir(filepath.Join(dir, ".git"), 0755)
+
+	rel := func(files []string) []string {
+		var res []string
+		for _, f := range files {
+			r, _ := filepath.Rel(dir, f)
+			res = append(res, filepath.ToSlash(r))
+		}
+		return res
+	}
+
+	tests := []struct {
+		args     []string
+		opts     walkOptions
+		expected []string
+	}{
+		{
+			[]string{dir},
+			walkOptions{exts: []string{".json"}, gitignore: true},
+			[]string{"a.JSON", "b.json", "sub/e.json", "sub/nested/deep/g.json", "sub/y.gen.json", "vendor/f.json"},
+		},
+		{
+			[]string{dir + "/sub/..."},
+			walkOptions{exts: []string{"json", "jsonc"}, gitignore: true},
+			[]string{"sub/e.json", "sub/nested/deep/g.json", "sub/nested/deep/h.jsonc", "sub/y.gen.json"},
+		},
+		{
+			[]string{dir},
+			walkOptions{exts: []string{".json"}, excludes: []string{"vendor", "*.gen.json"}},
+			[]string{"a.JSON", "b.json", "ignored/d.json", "sub/e.json", "sub/local.json", "sub/nested/deep/g.json"},
+		},
+		{
+			[]string{dir},
+			walkOptions{exts: []string{".json"}, includes: []string{"sub/**/*.json"}, gitignore: true},
+			[]string{"sub/e.json", "sub/nested/deep/g.json", "sub/y.gen.json"},
+		},
+		{
+			// Files given explicitly are always included.
+			[]string{filepath.Join(dir, "c.txt"), filepath.Join(dir, "ignored/d.json")},
+			walkOptions{exts: []string{".json"}, gitignore: true},
+			[]string{"c.txt", "ignored/d.json"},
+		},
+	}
+	for _, test := range tests {
+		files, err := collectFiles(test.args, test.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := rel(files); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%v: expected: %v, actual: %v", test.args, test.expected, actual)
+		}
+	}
+}
@@ -14,6 +14,8 @@ type ColorScheme struct {
 	Number    ColoredFormat
 	String    ColoredFormat
 	FieldName ColoredFormat
+	// Marker colors the markers of the values expanded from strings.
+	Marker ColoredFormat
 }
 
 var (
@@ -26,6 +28,7 @@ var (
 		Number:    NoColor,
 		String:    NoColor,
 		FieldName: NoColor,
+		Marker:    NoColor,
 	}
 )
 
@@ -116,3 +119,26 @@ func BoldCyan(format string, args ...interface{}) string {
 func BoldGray(format string, args ...interface{}) string {
 	return au.Sprintf(au.Colorize(au.Gray(uint8(12), format), au.BoldFm), args...)
 }
+
+// SGR returns a ColoredFormat that decorates the resulting string with
+// the given SGR parameters such as "1;31".
+// See: https://en.wikipedia.org/wiki/ANSI_escape_code#SGR
+func SGR(params string) ColoredFormat {
+	if params == "" {
+		return NoColor
+	}
+	return func(format string, args ...interface{}) string {
+		return "\x1b[" + params + "m" + fmt.Sprintf(format, args...) + "\x1b[0m"
+	}
+}
+
+// IsValidSGR reports whether params is a list of SGR parameters
+// separated by semicolons, such as "1;31". The empty string is valid.
+func IsValidSGR(params string) bool {
+	for _, r := range params {
+		if (r < '0' || r > '9') && r != ';' {
+			return false
+		}
+	}
+	return true
+}
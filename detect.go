@@ -0,0 +1,59 @@
+package jpp
+
+import (
+	"strings"
+)
+
+// Detected is the whitespace style of a json string.
+type Detected struct {
+	// Indent is the indentation unit such as "  " or "\t",
+	// or empty if the json string has no indented lines.
+	Indent string
+	// FinalNewline reports whether the json string ends with a newline.
+	FinalNewline bool
+}
+
+// Detect detects the indentation unit and the final newline of the json
+// string, so that it can be reformatted without changing them.
+// The indentation unit is the most common increase of the indentation
+// between consecutive lines.
+func Detect(jsonStr string) Detected {
+	return Detected{
+		Indent:       detectIndent(jsonStr),
+		FinalNewline: strings.HasSuffix(jsonStr, "\n"),
+	}
+}
+
+func detectIndent(jsonStr string) string {
+	tabs := 0
+	spaces := map[int]int{}
+	prev := 0
+	for _, line := range strings.Split(jsonStr, "\n") {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		indent := line[:len(line)-len(trimmed)]
+		if strings.HasPrefix(indent, "\t") {
+			tabs++
+			prev = 0
+			continue
+		}
+		if delta := len(indent) - prev; delta > 0 {
+			spaces[delta]++
+		}
+		prev = len(indent)
+	}
+
+	unit, count := 0, 0
+	for delta, c := range spaces {
+		if c > count || (c == count && delta < unit) {
+			unit, count = delta, c
+		}
+	}
+	if tabs > 0 && tabs >= count {
+		return "\t"
+	}
+	return strings.Repeat(" ", unit)
+}
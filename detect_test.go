@@ -0,0 +1,26 @@
+package jpp_test
+
+import (
+	"testing"
+
+	"github.com/tanishiking/jpp"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		jsonStr  string
+		expected jpp.Detected
+	}{
+		{`{"a": 1}`, jpp.Detected{Indent: "", FinalNewline: false}},
+		{"{\n  \"a\": 1\n}\n", jpp.Detected{Indent: "  ", FinalNewline: true}},
+		{"{\n    \"a\": {\n        \"b\": [\n            1\n        ]\n    }\n}", jpp.Detected{Indent: "    "}},
+		{"{\r\n\t\"a\": {\r\n\t\t\"b\": 1\r\n\t}\r\n}\r\n", jpp.Detected{Indent: "\t", FinalNewline: true}},
+		// Continuation lines aligned with other lines don't affect the unit.
+		{"{\n  \"a\": [\n    1, 2,\n    3\n  ],\n  \"b\": {\n    \"c\": 1\n  }\n}\n", jpp.Detected{Indent: "  ", FinalNewline: true}},
+	}
+	for _, test := range tests {
+		if actual := jpp.Detect(test.jsonStr); actual != test.expected {
+			t.Errorf("%q: expected: %+v, actual: %+v", test.jsonStr, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package jpp
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// expand returns the JSON object or array encoded in the string j at path,
+// unless there are already Options.ExpandStrings levels of expanded strings
+// around it. The expanded paths are recorded so that they are marked.
+func (pr *printer) expand(path Path, j gjson.Result) gjson.Result {
+	if pr.expandStrings <= 0 || j.Type != gjson.String {
+		return j
+	}
+	s := strings.TrimSpace(j.Str)
+	if s == "" || (s[0] != '{' && s[0] != '[') || !gjson.Valid(s) {
+		return j
+	}
+	level := 0
+	for i := range path {
+		if pr.expanded[path[:i].String()] {
+			level++
+		}
+	}
+	if level >= pr.expandStrings {
+		return j
+	}
+	pr.expanded[path.String()] = true
+	return gjson.Parse(s)
+}
+
+// isExpanded reports whether the value at path is expanded from a string.
+func (pr *printer) isExpanded(path Path) bool {
+	return pr.expandStrings > 0 && pr.expanded[path.String()]
+}
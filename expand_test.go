@@ -0,0 +1,80 @@
+package jpp_test
+
+import (
+	"testing"
+
+	"github.com/tanishiking/jpp"
+)
+
+func TestExpandStrings(t *testing.T) {
+	jsonStr := `{"payload": "{\"a\": 1, \"inner\": \"[true]\"}", "list": [" [1] ", "[oops", "plain"]}`
+	tests := []struct {
+		depth    int
+		expected string
+	}{
+		{0, `{
+  "payload": "{\"a\": 1, \"inner\": \"[true]\"}",
+  "list": [" [1] ", "[oops", "plain"]
+}`},
+		{1, `{
+  "payload": @json {"a": 1, "inner": "[true]"},
+  "list": [
+    @json [1],
+    "[oops",
+    "plain"
+  ]
+}`},
+		{2, `{
+  "payload": @json {
+    "a": 1,
+    "inner": @json [true]
+  },
+  "list": [
+    @json [1],
+    "[oops",
+    "plain"
+  ]
+}`},
+	}
+	for _, test := range tests {
+		actual, err := jpp.Format(jsonStr, jpp.Options{Indent: "  ", Width: 60, ExpandStrings: test.depth})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != test.expected {
+			t.Errorf("depth %v: got\n%v\nwant\n%v", test.depth, actual, test.expected)
+		}
+	}
+
+	scheme := &jpp.ColorScheme{Marker: jpp.SGR("35")}
+	actual, _ := jpp.Format(`"[]"`, jpp.Options{Styler: scheme, ExpandStrings: 1})
+	if expected := "\x1b[35m@json\x1b[0m []"; actual != expected {
+		t.Errorf("got %q, want %q", actual, expected)
+	}
+}
+
+func TestExpandStrings_sourceMap(t *testing.T) {
+	jsonStr := `{"a": "{\"b\": 1}", "c": 2}`
+	res, mappings, err := jpp.FormatWithSourceMap(jsonStr, jpp.Options{Indent: "  ", Width: 80, ExpandStrings: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range mappings {
+		out, in := res[m.Output.Start:m.Output.End], jsonStr[m.Input.Start:m.Input.End]
+		switch m.Path.String() {
+		case "$.a":
+			if m.Kind == jpp.KeyToken {
+				continue
+			}
+			fallthrough
+		case "$.a.b":
+			if in != `"{\"b\": 1}"` {
+				t.Errorf("%v %v is mapped to %v, want the whole string", m.Path, out, in)
+			}
+		case "$.c":
+			if out != in {
+				t.Errorf("%v %v is mapped to %v", m.Path, out, in)
+			}
+		}
+	}
+}
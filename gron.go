@@ -0,0 +1,247 @@
+package jpp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Gron flattens jsonStr into assignments, one per line, such as
+// `json.items[0].name = "foo";`, so that it can be grepped.
+// Containers are assigned `{}` or `[]` before their members.
+// The tokens are decorated by opts.Styler, and members are sorted if
+// opts.SortKeys is set, and redacted by opts.Redaction. The other options
+// are ignored.
+func Gron(jsonStr string, opts Options) (string, error) {
+	if !gjson.Valid(jsonStr) {
+		return "", errors.New("parse error: Invalid json input")
+	}
+	pr := newPrinter(opts)
+	pr.expandStrings = 0
+	var b bytes.Buffer
+	pr.gronRec(&b, Path{}, gjson.Parse(jsonStr))
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+func (pr *printer) gronRec(b *bytes.Buffer, path Path, j gjson.Result) {
+	j = pr.redact(path, j)
+	pr.writeGronPath(b, path)
+	pr.writeToken(b, PunctuationToken, path, " = ")
+	switch {
+	case j.IsArray():
+		pr.writeToken(b, PunctuationToken, path, "[]")
+		pr.writeToken(b, PunctuationToken, path, ";")
+		b.WriteString("\n")
+		for i, item := range pr.elements(path, j) {
+			pr.gronRec(b, path.Append(i), item.value)
+		}
+	case j.IsObject():
+		pr.writeToken(b, PunctuationToken, path, "{}")
+		pr.writeToken(b, PunctuationToken, path, ";")
+		b.WriteString("\n")
+		for _, m := range pr.members(path, j) {
+			pr.gronRec(b, path.Append(m.key.Str), m.value)
+		}
+	default:
+		kind, text := scalarToken(j)
+		pr.writeToken(b, kind, path, text)
+		pr.writeToken(b, PunctuationToken, path, ";")
+		b.WriteString("\n")
+	}
+}
+
+// writeGronPath writes path such as `json.items[0]["a/b"]`.
+func (pr *printer) writeGronPath(b *bytes.Buffer, path Path) {
+	pr.writeToken(b, KeyToken, Path{}, "json")
+	for i, elem := range path {
+		elemPath := path[:i+1]
+		switch e := elem.(type) {
+		case int:
+			pr.writeToken(b, PunctuationToken, elemPath, "[")
+			pr.writeToken(b, NumberToken, elemPath, strconv.Itoa(e))
+			pr.writeToken(b, PunctuationToken, elemPath, "]")
+		case string:
+			if isIdentifier(e) {
+				pr.writeToken(b, PunctuationToken, elemPath, ".")
+				pr.writeToken(b, KeyToken, elemPath, e)
+			} else {
+				pr.writeToken(b, PunctuationToken, elemPath, "[")
+				pr.writeToken(b, KeyToken, elemPath, encodeString(e))
+				pr.writeToken(b, PunctuationToken, elemPath, "]")
+			}
+		}
+	}
+}
+
+// ansiEscape matches the SGR escape sequences of colored gron output.
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// MaxUngronIndex is the largest index of arrays Ungron accepts, since the
+// missing elements before it are filled with null.
+const MaxUngronIndex = 1<<24 - 1
+
+// Ungron rebuilds the compact JSON from the assignments of Gron.
+// The lines may be filtered, e.g. by grep: missing containers are created,
+// and missing elements of arrays are filled with null.
+func Ungron(gron string) (string, error) {
+	var root *gronNode
+	for i, line := range strings.Split(ansiEscape.ReplaceAllString(gron, ""), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		path, value, err := parseGronLine(line)
+		if err != nil {
+			return "", fmt.Errorf("line %v: %v", i+1, err)
+		}
+		root = root.assign(path, value)
+	}
+	if root == nil {
+		return "", errors.New("no assignments")
+	}
+	var b bytes.Buffer
+	root.write(&b)
+	return b.String(), nil
+}
+
+// parseGronLine parses an assignment such as `json.items[0]["a/b"] = 1;`.
+func parseGronLine(line string) (Path, string, error) {
+	if !strings.HasPrefix(line, "json") {
+		return nil, "", errors.New("an assignment must start with json")
+	}
+	rest := line[len("json"):]
+	var path Path
+	for !strings.HasPrefix(rest, " ") && !strings.HasPrefix(rest, "=") {
+		switch {
+		case strings.HasPrefix(rest, "."):
+			n := 1
+			for n < len(rest) && isIdentifier(rest[1:n+1]) {
+				n++
+			}
+			if n == 1 {
+				return nil, "", fmt.Errorf("invalid key in %q", line)
+			}
+			path = append(path, rest[1:n])
+			rest = rest[n:]
+		case strings.HasPrefix(rest, "[\""):
+			// The key ends at the first unescaped quote.
+			n := 2
+			for n < len(rest) && rest[n] != '"' {
+				if rest[n] == '\\' {
+					n++
+				}
+				n++
+			}
+			var key string
+			if n >= len(rest) || json.Unmarshal([]byte(rest[1:n+1]), &key) != nil || !strings.HasPrefix(rest[n+1:], "]") {
+				return nil, "", fmt.Errorf("invalid key in %q", line)
+			}
+			path = append(path, key)
+			rest = rest[n+2:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, "", fmt.Errorf("invalid index in %q", line)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, "", fmt.Errorf("invalid index in %q", line)
+			}
+			if index > MaxUngronIndex {
+				return nil, "", fmt.Errorf("index %v in %q is larger than %v", index, line, MaxUngronIndex)
+			}
+			path = append(path, index)
+			rest = rest[end+1:]
+		default:
+			return nil, "", fmt.Errorf("invalid path in %q", line)
+		}
+	}
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, "=") || !strings.HasSuffix(rest, ";") {
+		return nil, "", fmt.Errorf("invalid assignment %q", line)
+	}
+	value := strings.TrimSpace(rest[1 : len(rest)-1])
+	if !gjson.Valid(value) || (gjson.Parse(value).Type == gjson.JSON && value != "{}" && value != "[]") {
+		return nil, "", fmt.Errorf("invalid value %q", value)
+	}
+	return path, value, nil
+}
+
+// gronNode is a value rebuilt by Ungron.
+type gronNode struct {
+	// raw is the JSON of a scalar, "{}" for objects or "[]" for arrays.
+	raw     string
+	keys    []string
+	members map[string]*gronNode
+	items   []*gronNode
+}
+
+func newGronNode(raw string) *gronNode {
+	return &gronNode{raw: raw, members: map[string]*gronNode{}}
+}
+
+// assign sets value at path under n, and returns the new n.
+func (n *gronNode) assign(path Path, value string) *gronNode {
+	if len(path) == 0 {
+		// Assigning a container again keeps its members.
+		if n != nil && n.raw == value && (value == "{}" || value == "[]") {
+			return n
+		}
+		return newGronNode(value)
+	}
+	switch e := path[0].(type) {
+	case string:
+		if n == nil || n.raw != "{}" {
+			n = newGronNode("{}")
+		}
+		child := n.members[e].assign(path[1:], value)
+		if _, ok := n.members[e]; !ok {
+			n.keys = append(n.keys, e)
+		}
+		n.members[e] = child
+	case int:
+		if n == nil || n.raw != "[]" {
+			n = newGronNode("[]")
+		}
+		for len(n.items) <= e {
+			n.items = append(n.items, nil)
+		}
+		n.items[e] = n.items[e].assign(path[1:], value)
+	}
+	return n
+}
+
+func (n *gronNode) write(b *bytes.Buffer) {
+	switch {
+	case n == nil:
+		b.WriteString("null")
+	case n.raw == "{}":
+		b.WriteString("{")
+		for i, key := range n.keys {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(encodeString(key))
+			b.WriteString(":")
+			n.members[key].write(b)
+		}
+		b.WriteString("}")
+	case n.raw == "[]":
+		b.WriteString("[")
+		for i, item := range n.items {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			item.write(b)
+		}
+		b.WriteString("]")
+	default:
+		b.WriteString(n.raw)
+	}
+}
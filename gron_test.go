@@ -0,0 +1,83 @@
+package jpp_test
+
+import (
+	"testing"
+
+	"github.com/tanishiking/jpp"
+)
+
+func TestGron(t *testing.T) {
+	jsonStr := `{"items": [{"name": "foo", "a/b": null}, []], "n": 1.5, "e": {}}`
+	expected := `json = {};
+json.items = [];
+json.items[0] = {};
+json.items[0].name = "foo";
+json.items[0]["a/b"] = null;
+json.items[1] = [];
+json.n = 1.5;
+json.e = {};`
+	actual, err := jpp.Gron(jsonStr, jpp.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual != expected {
+		t.Errorf("got\n%v\nwant\n%v", actual, expected)
+	}
+
+	colored, err := jpp.Gron(`{"a": "b"}`, jpp.Options{Styler: &jpp.ColorScheme{
+		Null: jpp.NoColor, Bool: jpp.NoColor, Number: jpp.NoColor, String: jpp.Green, FieldName: jpp.Blue,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if colored != "\x1b[34mjson\x1b[0m = {};\n\x1b[34mjson\x1b[0m.\x1b[34ma\x1b[0m = \x1b[32m\"b\"\x1b[0m;" {
+		t.Errorf("got %q", colored)
+	}
+
+	// Ungron rebuilds the input, even from colored output.
+	tests := []struct {
+		gron     string
+		expected string
+	}{
+		{actual, `{"items":[{"name":"foo","a/b":null},[]],"n":1.5,"e":{}}`},
+		{colored, `{"a":"b"}`},
+	}
+	for _, test := range tests {
+		rebuilt, err := jpp.Ungron(test.gron)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rebuilt != test.expected {
+			t.Errorf("got %v by ungron, want %v", rebuilt, test.expected)
+		}
+	}
+}
+
+func TestUngron(t *testing.T) {
+	tests := []struct {
+		gron     string
+		expected string
+	}{
+		{"json.items[0].name = \"foo\";", `{"items":[{"name":"foo"}]}`},
+		// Filtered by grep.
+		{"json.a[2] = true;\njson[\"a b\"].c = \"x;y\";\n", `{"a":[null,null,true],"a b":{"c":"x;y"}}`},
+		{"json = {};\njson.a = {};\njson.a.b = 1;\njson.a = {};", `{"a":{"b":1}}`},
+		{"json = 1;", `1`},
+	}
+	for _, test := range tests {
+		actual, err := jpp.Ungron(test.gron)
+		if err != nil {
+			t.Errorf("%q: %v", test.gron, err)
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("Ungron(%q) = %v, want %v", test.gron, actual, test.expected)
+		}
+	}
+
+	for _, gron := range []string{"", "foo = 1;", "json.a = 1", "json.a = [1];", "json[x] = 1;", "json.a = nul;", "json[1000000000] = 1;"} {
+		if _, err := jpp.Ungron(gron); err == nil {
+			t.Errorf("Ungron(%q) succeeded, want an error", gron)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package jpp
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// htmlTextEscaper escapes the text content of an element.
+// Quotes don't need to be escaped outside attributes.
+var htmlTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// HTMLStyler is a Styler that wraps each token in a <span> element whose
+// class is "jpp-" followed by the kind of the token, such as "jpp-key".
+// The text of the token is HTML-escaped.
+type HTMLStyler struct {
+	// Collapsible wraps the content of each container in a <details>
+	// element so that it can be folded in a browser.
+	Collapsible bool
+}
+
+// Style implements Styler.
+func (h HTMLStyler) Style(kind TokenKind, path Path, text string) (string, int) {
+	width := len([]rune(text))
+	span := fmt.Sprintf(`<span class="jpp-%v">%v</span>`, kind, htmlTextEscaper.Replace(text))
+	if h.Collapsible && kind == PunctuationToken {
+		switch text {
+		case "{", "[":
+			return `<details class="jpp-container" open><summary>` + span + `</summary>`, width
+		case "}", "]":
+			return `</details>` + span, width
+		}
+	}
+	return span, width
+}
+
+// CSS returns a style sheet for the elements emitted by HTMLStyler,
+// colored according to the color scheme.
+func (c *ColorScheme) CSS() string {
+	var b bytes.Buffer
+	b.WriteString(".jpp { font-family: monospace; white-space: pre; }\n")
+	for _, kind := range coloredKinds {
+		if css := styleOf(c.colorOf(kind)).css(); css != "" {
+			fmt.Fprintf(&b, ".jpp-%v { %v }\n", kind, css)
+		}
+	}
+	b.WriteString(".jpp details, .jpp summary { display: inline; }\n")
+	b.WriteString(".jpp summary { cursor: pointer; list-style: none; }\n")
+	b.WriteString(".jpp summary::-webkit-details-marker { display: none; }\n")
+	b.WriteString(".jpp details:not([open]) > summary::after { content: \"…\"; }\n")
+	return b.String()
+}
+
+// HTMLOptions configures FormatHTML.
+type HTMLOptions struct {
+	// ColorScheme is used to generate the style sheet of a standalone page.
+	// DefaultScheme is used if it is nil.
+	ColorScheme *ColorScheme
+	// Standalone renders a whole HTML page with a style sheet and
+	// collapsible containers instead of a <pre> fragment.
+	Standalone bool
+	// Title is the title of a standalone page.
+	Title string
+}
+
+// FormatHTML prettifies specified json string like Format and renders it
+// as HTML. The line breaks are identical to the ones Format emits.
+// The Styler in opts is ignored.
+func FormatHTML(jsonStr string, opts Options, htmlOpts HTMLOptions) (string, error) {
+	opts.Styler = HTMLStyler{Collapsible: htmlOpts.Standalone}
+	res, err := Format(jsonStr, opts)
+	if err != nil {
+		return "", err
+	}
+	fragment := `<pre class="jpp">` + res + "</pre>"
+	if !htmlOpts.Standalone {
+		return fragment, nil
+	}
+
+	scheme := htmlOpts.ColorScheme
+	if scheme == nil {
+		scheme = DefaultScheme
+	}
+	title := htmlOpts.Title
+	if title == "" {
+		title = "jpp"
+	}
+	var b bytes.Buffer
+	b.WriteString("<!DOCTYPE html>\n")
+	b.WriteString("<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%v</title>\n", html.EscapeString(title))
+	fmt.Fprintf(&b, "<style>\n%v</style>\n", scheme.CSS())
+	b.WriteString("</head>\n<body>\n")
+	b.WriteString(fragment)
+	b.WriteString("\n</body>\n</html>")
+	return b.String(), nil
+}
@@ -0,0 +1,71 @@
+package jpp_test
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/tanishiking/jpp"
+)
+
+func TestFormatHTML(t *testing.T) {
+	jsonStr := `{"tag": "<a href=\"x\">&</a>", "n": [1, null]}`
+	actual, _ := jpp.FormatHTML(jsonStr, jpp.Options{Indent: "  ", Width: 100}, jpp.HTMLOptions{})
+	expected := `<pre class="jpp">` +
+		`<span class="jpp-punctuation">{</span>` + "\n  " +
+		`<span class="jpp-key">"tag"</span><span class="jpp-punctuation">:</span> ` +
+		`<span class="jpp-string">"&lt;a href=\"x\"&gt;&amp;&lt;/a&gt;"</span><span class="jpp-punctuation">,</span>` + "\n  " +
+		`<span class="jpp-key">"n"</span><span class="jpp-punctuation">:</span> ` +
+		`<span class="jpp-punctuation">[</span><span class="jpp-number">1</span><span class="jpp-punctuation">,</span> <span class="jpp-null">null</span><span class="jpp-punctuation">]</span>` + "\n" +
+		`<span class="jpp-punctuation">}</span>` +
+		`</pre>`
+	if actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestFormatHTML_SameLineBreaks(t *testing.T) {
+	jsonStr := `{"numbers": [[1,2,3,4,5], [6,7,8,9,10]], "object": {"name": "foo", "description": "<bar>"}}`
+	opts := jpp.Options{Indent: "  ", Width: 15}
+	text, _ := jpp.Format(jsonStr, opts)
+	for _, standalone := range []bool{false, true} {
+		res, _ := jpp.FormatHTML(jsonStr, opts, jpp.HTMLOptions{Standalone: standalone})
+		pre := res[strings.Index(res, "<pre"):strings.Index(res, "</pre>")]
+		stripped := html.UnescapeString(regexp.MustCompile(`<[^>]*>`).ReplaceAllString(pre, ""))
+		if stripped != text {
+			t.Errorf("expected: %v, actual: %v", text, stripped)
+		}
+	}
+}
+
+func TestFormatHTML_Standalone(t *testing.T) {
+	scheme := &jpp.ColorScheme{
+		Null:      jpp.NoColor,
+		Bool:      jpp.Red,
+		Number:    jpp.SGR("38;5;196"),
+		String:    jpp.SGR("38;2;1;2;3"),
+		FieldName: jpp.BoldBlue,
+	}
+	res, _ := jpp.FormatHTML(`{"a": [true]}`, jpp.Options{Indent: "  ", Width: 100}, jpp.HTMLOptions{
+		ColorScheme: scheme,
+		Standalone:  true,
+		Title:       "<example>",
+	})
+	for _, expected := range []string{
+		"<title>&lt;example&gt;</title>",
+		".jpp-bool { color: #cd0000; }",
+		".jpp-number { color: #ff0000; }",
+		".jpp-string { color: #010203; }",
+		".jpp-key { color: #0000ee; font-weight: bold; }",
+		`<details class="jpp-container" open><summary><span class="jpp-punctuation">[</span></summary>`,
+		`</details><span class="jpp-punctuation">]</span>`,
+	} {
+		if !strings.Contains(res, expected) {
+			t.Errorf("expected %v to be contained in %v", expected, res)
+		}
+	}
+	if strings.Contains(res, ".jpp-null") {
+		t.Errorf("expected no rule for uncolored tokens: %v", res)
+	}
+}
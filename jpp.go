@@ -5,213 +5,424 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	p "github.com/tanishiking/prettier"
 	"github.com/tidwall/gjson"
 )
 
-var (
-	indent   string
-	width    int
-	coloring *ColorScheme
+// Options configures the output of Format.
+type Options struct {
+	// Indent is the indentation string.
+	Indent string
+	// Width is the width jpp attempts to keep lines within.
+	Width int
+	// Styler decorates each token. DefaultScheme is used if it is nil.
+	Styler Styler
+	// SortKeys sorts the members of objects by their keys.
+	SortKeys bool
+	// Layout is the strategy to lay out arrays and objects.
+	Layout Layout
+	// TabWidth is the display width of a tab in the indentation,
+	// which is used to keep lines within Width.
+	// DefaultTabWidth is used if it is zero.
+	TabWidth int
+	// Redaction replaces the values it selects with RedactedValue.
+	// Nothing is redacted if it is nil.
+	Redaction *Redaction
+	// ExpandStrings prints the strings that encode JSON objects or arrays
+	// as the nested values marked with MarkerToken, up to this many levels
+	// of strings in strings. Nothing is expanded if it is zero.
+	// Gron, FormatShape and Diff ignore it.
+	ExpandStrings int
+}
+
+// Layout is the strategy to lay out arrays and objects.
+type Layout int
+
+const (
+	// LayoutCompact tries to fit arrays and objects whose values are all
+	// scalar in as few lines as possible. This is the default.
+	LayoutCompact Layout = iota
+	// LayoutExpanded prints each value on its own line.
+	LayoutExpanded
 )
 
+// String returns the name of the layout.
+func (l Layout) String() string {
+	switch l {
+	case LayoutCompact:
+		return "compact"
+	case LayoutExpanded:
+		return "expanded"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseLayout returns the layout of the name, "compact" or "expanded".
+func ParseLayout(name string) (Layout, error) {
+	switch name {
+	case "compact":
+		return LayoutCompact, nil
+	case "expanded":
+		return LayoutExpanded, nil
+	default:
+		return LayoutCompact, fmt.Errorf("unknown layout %q: must be compact or expanded", name)
+	}
+}
+
 // Pretty prettifies specified json string.
 func Pretty(jsonStr string, i string, w int, colorScheme *ColorScheme) (string, error) {
+	opts := Options{Indent: i, Width: w}
+	if colorScheme != nil {
+		opts.Styler = colorScheme
+	}
+	return Format(jsonStr, opts)
+}
+
+// Format prettifies specified json string according to opts.
+func Format(jsonStr string, opts Options) (string, error) {
 	if !gjson.Valid(jsonStr) {
 		return "", errors.New("parse error: Invalid json input")
 	}
 	json := gjson.Parse(jsonStr)
 
-	indent = i
-	width = w
-	if colorScheme != nil {
-		coloring = colorScheme
-	} else {
-		coloring = DefaultScheme
-	}
-
+	pr := newPrinter(opts)
 	var builder bytes.Buffer
-	prettyRec(&builder, 0, json)
+	pr.prettyRec(&builder, 0, Path{}, json)
 	return builder.String(), nil
 }
 
-func prettyRec(b *bytes.Buffer, depth int, j gjson.Result) {
+// printer holds the settings of a single Format call,
+// so that Format is safe for concurrent use.
+type printer struct {
+	indent    string
+	width     int
+	styler    Styler
+	sortKeys  bool
+	layout    Layout
+	tabWidth  int
+	redaction *Redaction
+	// expandStrings is Options.ExpandStrings, and expanded is the set of
+	// the paths of the values expanded from strings.
+	expandStrings int
+	expanded      map[string]bool
+	// prefix is written at the beginning of each line before the indentation.
+	prefix string
+	// sourceMap is set to record the mappings of the tokens to src.
+	sourceMap bool
+	src       string
+	mappings  []Mapping
+	// within is the span of the string in src whose expanded value is
+	// being printed, where all the tokens are mapped to the string.
+	within *Span
+	// docTokens are the mappings of the tokens in the doc being built,
+	// whose spans in the output are found by writeDoc.
+	docTokens []Mapping
+}
+
+func newPrinter(opts Options) *printer {
+	pr := &printer{
+		indent:        opts.Indent,
+		width:         opts.Width,
+		styler:        opts.Styler,
+		sortKeys:      opts.SortKeys,
+		layout:        opts.Layout,
+		tabWidth:      opts.TabWidth,
+		redaction:     opts.Redaction,
+		expandStrings: opts.ExpandStrings,
+		expanded:      map[string]bool{},
+	}
+	if pr.styler == nil {
+		pr.styler = DefaultScheme
+	}
+	if pr.tabWidth <= 0 {
+		pr.tabWidth = DefaultTabWidth
+	}
+	return pr
+}
+
+// DefaultTabWidth is the display width of a tab used if Options.TabWidth is zero.
+const DefaultTabWidth = 8
+
+// columns returns the display width of the indentation at depth,
+// where tabs advance to the next tab stop.
+func (pr *printer) columns(depth int) int {
+	col := pr.advance(0, pr.prefix)
+	for i := 0; i < depth; i++ {
+		col = pr.advance(col, pr.indent)
+	}
+	return col
+}
+
+// advance returns the column after writing the whitespace ws at col.
+func (pr *printer) advance(col int, ws string) int {
+	for _, r := range ws {
+		if r == '\t' {
+			col += pr.tabWidth - col%pr.tabWidth
+		} else {
+			col++
+		}
+	}
+	return col
+}
+
+// nestWidth returns the display width one level of the indentation adds at depth.
+func (pr *printer) nestWidth(depth int) int {
+	return pr.columns(depth+1) - pr.columns(depth)
+}
+
+// render lays out the doc of a container at depth.
+// prettier indents the lines in the container with spaces of nestWidth
+// for each level, so they are replaced by the indentation string.
+func (pr *printer) render(doc p.Doc, depth int) string {
+	rendered := p.Pretty(pr.width-pr.columns(depth), doc)
+	lines := strings.Split(rendered, "\n")
+	nest := strings.Repeat(" ", pr.nestWidth(depth))
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\n")
+			b.WriteString(pr.prefix)
+			b.WriteString(strings.Repeat(pr.indent, depth))
+			for nest != "" && strings.HasPrefix(line, nest) {
+				b.WriteString(pr.indent)
+				line = line[len(nest):]
+			}
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// member is a key-value pair of a json object, or an element of a json
+// array without the key.
+type member struct {
+	key   gjson.Result
+	value gjson.Result
+	// source is the value in the input before the redaction and the expansion.
+	source gjson.Result
+}
+
+// members returns the members of the json object j at path in the printed
+// order, where the values are redacted and expanded.
+func (pr *printer) members(path Path, j gjson.Result) []member {
+	var ms []member
+	j.ForEach(func(k gjson.Result, v gjson.Result) bool {
+		// The indexes of the members are relative to j.
+		k.Index += j.Index
+		v.Index += j.Index
+		valuePath := path.Append(k.Str)
+		ms = append(ms, member{key: k, value: pr.expand(valuePath, pr.redact(valuePath, v)), source: v})
+		return true
+	})
+	if pr.sortKeys {
+		sort.SliceStable(ms, func(a, b int) bool {
+			return ms[a].key.Str < ms[b].key.Str
+		})
+	}
+	return ms
+}
+
+// elements returns the elements of the json array j at path, where the
+// elements are redacted and expanded.
+func (pr *printer) elements(path Path, j gjson.Result) []member {
+	var items []member
+	j.ForEach(func(_, v gjson.Result) bool {
+		v.Index += j.Index
+		itemPath := path.Append(len(items))
+		items = append(items, member{value: pr.expand(itemPath, pr.redact(itemPath, v)), source: v})
+		return true
+	})
+	return items
+}
+
+func (pr *printer) prettyRec(b *bytes.Buffer, depth int, path Path, j gjson.Result) {
+	j = pr.redact(path, j)
+	source := j
+	j = pr.expand(path, j)
 	switch j.Type {
-	case gjson.Null:
-		color := coloring.Null
-		b.WriteString(color("null"))
-	case gjson.False:
-		color := coloring.Bool
-		b.WriteString(color("false"))
-	case gjson.Number:
-		color := coloring.Number
-		b.WriteString(color(formatNum(j.Num)))
-	case gjson.String:
-		color := coloring.String
-		b.WriteString(color("\"%v\"", j.Str))
-	case gjson.True:
-		color := coloring.Bool
-		b.WriteString(color("true"))
 	case gjson.JSON:
+		if pr.isExpanded(path) {
+			if pr.sourceMap && pr.within == nil {
+				pr.within = &Span{Start: source.Index, End: source.Index + len(source.Raw)}
+				defer func() { pr.within = nil }()
+			}
+			pr.writeMapped(b, MarkerToken, path, "@json", Span{})
+			b.WriteString(" ")
+		}
 		if j.IsArray() {
-			items := j.Array()
-			if allElemsAreScalar(items) {
+			items := pr.elements(path, j)
+			if len(items) == 0 {
+				pr.writeMapped(b, PunctuationToken, path, "[", opening(j))
+				pr.writeMapped(b, PunctuationToken, path, "]", closing(j))
+			} else if pr.layout == LayoutCompact && allElemsAreScalar(items) {
 				// Try to fit the json array in a single line
 				// if all items are scalar values.
-				indentLength := len([]rune(indent))
-				sep := p.Concat([]p.Doc{p.Text(","), p.LineOrSpace()})
+				open := pr.mappedToken(PunctuationToken, path, "[", opening(j))
 				ds := make([]p.Doc, 0, len(items))
-				for _, item := range items {
-					ds = append(ds, toDoc(item))
+				for i, item := range items {
+					d := pr.toDoc(path.Append(i), item.value)
+					if i != len(items)-1 {
+						d = p.Concat([]p.Doc{d, pr.mappedToken(PunctuationToken, path, ",", pr.after(item.source, ','))})
+					}
+					ds = append(ds, d)
 				}
 				doc := p.TightBracketBy(
-					p.Text("["),
-					p.Text("]"),
-					p.Intercalate(sep, ds),
-					uint(indentLength),
-				)
-				layout := strings.Replace(
-					p.Pretty(width-indentLength*depth, doc),
-					"\n",
-					fmt.Sprintf("\n%v", strings.Repeat(indent, depth)),
-					-1,
+					open,
+					pr.mappedToken(PunctuationToken, path, "]", closing(j)),
+					p.Intercalate(p.LineOrSpace(), ds),
+					uint(pr.nestWidth(depth)),
 				)
-				b.WriteString(layout)
+				pr.writeDoc(b, doc, depth)
 			} else {
-				b.WriteString("[")
+				pr.writeMapped(b, PunctuationToken, path, "[", opening(j))
 				depthInBracket := depth + 1
-				newline(b, indent, depthInBracket)
+				pr.newline(b, depthInBracket)
 				for i, item := range items {
-					prettyRec(b, depthInBracket, item)
+					pr.prettyRec(b, depthInBracket, path.Append(i), item.source)
 					if i != len(items)-1 {
-						b.WriteString(",")
-						newline(b, indent, depthInBracket)
+						pr.writeMapped(b, PunctuationToken, path, ",", pr.after(item.source, ','))
+						pr.newline(b, depthInBracket)
 					}
 				}
-				newline(b, indent, depth)
-				b.WriteString("]")
+				pr.newline(b, depth)
+				pr.writeMapped(b, PunctuationToken, path, "]", closing(j))
 			}
 		} else {
-			m := j.Map()
-			if allValuesAreScalar(m) {
+			ms := pr.members(path, j)
+			if len(ms) == 0 {
+				pr.writeMapped(b, PunctuationToken, path, "{", opening(j))
+				pr.writeMapped(b, PunctuationToken, path, "}", closing(j))
+			} else if pr.layout == LayoutCompact && allValuesAreScalar(ms) {
 				// Try to fit the json object in a single line
 				// if all values of the json object are scalar values.
-				indentLength := len([]rune(indent))
-				sep := p.Concat([]p.Doc{p.Text(","), p.LineOrSpace()})
-				var kvs []p.Doc
-				color := coloring.FieldName
-				j.ForEach(func(k gjson.Result, v gjson.Result) bool {
-					length := len([]rune(k.Str))
-					kv := p.Concat([]p.Doc{
-						p.TextWithLength(color("\"%v\"", k.Str), length),
-						p.Text(":"),
+				open := pr.mappedToken(PunctuationToken, path, "{", opening(j))
+				kvs := make([]p.Doc, 0, len(ms))
+				for i, m := range ms {
+					valuePath := path.Append(m.key.Str)
+					kv := []p.Doc{
+						pr.mappedToken(KeyToken, valuePath, encodeString(m.key.Str), valueSpan(m.key)),
+						pr.mappedToken(PunctuationToken, path, ":", pr.after(m.key, ':')),
 						p.Text(" "),
-						toDoc(v),
-					})
-					kvs = append(kvs, kv)
-					return true
-				})
+						pr.toDoc(valuePath, m.value),
+					}
+					if i != len(ms)-1 {
+						kv = append(kv, pr.mappedToken(PunctuationToken, path, ",", pr.after(m.source, ',')))
+					}
+					kvs = append(kvs, p.Concat(kv))
+				}
 				doc := p.TightBracketBy(
-					p.Text("{"),
-					p.Text("}"),
-					p.Fill(sep, kvs),
-					uint(indentLength),
+					open,
+					pr.mappedToken(PunctuationToken, path, "}", closing(j)),
+					p.Fill(p.LineOrSpace(), kvs),
+					uint(pr.nestWidth(depth)),
 				)
-				layout := strings.Replace(
-					p.Pretty(width-indentLength*depth, doc),
-					"\n",
-					fmt.Sprintf("\n%v", strings.Repeat(indent, depth)),
-					-1,
-				)
-				b.WriteString(layout)
+				pr.writeDoc(b, doc, depth)
 			} else {
-				b.WriteString("{")
+				pr.writeMapped(b, PunctuationToken, path, "{", opening(j))
 				depthInBracket := depth + 1
-				newline(b, indent, depthInBracket)
-				len := len(m)
-				i := 0
-				color := coloring.FieldName
-				j.ForEach(func(k gjson.Result, v gjson.Result) bool {
-					b.WriteString(color("\"%v\"", k.Str))
-					b.WriteString(":")
+				pr.newline(b, depthInBracket)
+				for i, m := range ms {
+					valuePath := path.Append(m.key.Str)
+					pr.writeMapped(b, KeyToken, valuePath, encodeString(m.key.Str), valueSpan(m.key))
+					pr.writeMapped(b, PunctuationToken, path, ":", pr.after(m.key, ':'))
 					b.WriteString(" ")
-					prettyRec(b, depthInBracket, v)
-					if i != len-1 {
-						b.WriteString(",")
-						newline(b, indent, depthInBracket)
+					pr.prettyRec(b, depthInBracket, valuePath, m.source)
+					if i != len(ms)-1 {
+						pr.writeMapped(b, PunctuationToken, path, ",", pr.after(m.source, ','))
+						pr.newline(b, depthInBracket)
 					}
-					i++
-					return true
-				})
-				newline(b, indent, depth)
-				b.WriteString("}")
+				}
+				pr.newline(b, depth)
+				pr.writeMapped(b, PunctuationToken, path, "}", closing(j))
 			}
 		}
+	default:
+		kind, text := scalarToken(j)
+		pr.writeMapped(b, kind, path, text, valueSpan(j))
 	}
 }
 
-func newline(dst *bytes.Buffer, indent string, depth int) {
+func (pr *printer) newline(dst *bytes.Buffer, depth int) {
 	dst.WriteByte('\n')
+	dst.WriteString(pr.prefix)
 	for i := 0; i < depth; i++ {
-		dst.WriteString(indent)
+		dst.WriteString(pr.indent)
 	}
 }
 
+func (pr *printer) writeToken(dst *bytes.Buffer, kind TokenKind, path Path, text string) {
+	styled, _ := pr.styler.Style(kind, path, text)
+	dst.WriteString(styled)
+}
+
+// token converts the token to p.Doc decorated by the styler.
+func (pr *printer) token(kind TokenKind, path Path, text string) p.Doc {
+	styled, length := pr.styler.Style(kind, path, text)
+	return p.TextWithLength(styled, length)
+}
+
+func (pr *printer) punct(path Path, text string) p.Doc {
+	return pr.token(PunctuationToken, path, text)
+}
+
 // toDoc convert gjson.Result to p.Doc
 // note that we need to confirm that j is not gjson.JSON.
-func toDoc(j gjson.Result) p.Doc {
-	switch j.Type {
-	default:
+func (pr *printer) toDoc(path Path, j gjson.Result) p.Doc {
+	if j.Type == gjson.JSON {
 		return p.Empty()
+	}
+	kind, text := scalarToken(j)
+	return pr.mappedToken(kind, path, text, valueSpan(j))
+}
+
+// scalarToken returns the kind and the text of the scalar value j.
+func scalarToken(j gjson.Result) (TokenKind, string) {
+	switch j.Type {
 	case gjson.Null:
-		color := coloring.Null
-		str := "null"
-		length := len([]rune(str))
-		return p.TextWithLength(color(str), length)
+		return NullToken, "null"
 	case gjson.False:
-		color := coloring.Bool
-		str := "false"
-		length := len([]rune(str))
-		return p.TextWithLength(color(str), length)
+		return BoolToken, "false"
 	case gjson.Number:
-		color := coloring.Number
-		str := formatNum(j.Num)
-		length := len([]rune(str))
-		return p.TextWithLength(color(str), length)
+		// The literal is kept as is, since float64 loses the precision
+		// of big integers and can't represent out-of-range exponents.
+		return NumberToken, strings.TrimSpace(j.Raw)
 	case gjson.String:
-		color := coloring.String
-		str := fmt.Sprintf("\"%v\"", j.Str)
-		length := len([]rune(str))
-		return p.TextWithLength(color(str), length)
+		return StringToken, encodeString(j.Str)
 	case gjson.True:
-		color := coloring.Bool
-		str := "true"
-		length := len([]rune(str))
-		return p.TextWithLength(color(str), length)
+		return BoolToken, "true"
+	default:
+		return NullToken, ""
 	}
 }
 
-func allElemsAreScalar(arr []gjson.Result) bool {
+func allElemsAreScalar(arr []member) bool {
 	for _, v := range arr {
-		if v.Type == gjson.JSON {
+		if v.value.Type == gjson.JSON {
 			return false
 		}
 	}
 	return true
 }
 
-func allValuesAreScalar(m map[string]gjson.Result) bool {
-	for _, v := range m {
-		if v.Type == gjson.JSON {
+func allValuesAreScalar(ms []member) bool {
+	for _, m := range ms {
+		if m.value.Type == gjson.JSON {
 			return false
 		}
 	}
 	return true
 }
 
-func formatNum(num float64) string {
-	data, _ := json.Marshal(num)
-	return string(data)
+// encodeString encodes str as a JSON string.
+// Unlike json.Marshal, it doesn't escape HTML characters.
+func encodeString(str string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(str)
+	return strings.TrimSuffix(b.String(), "\n")
 }
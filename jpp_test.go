@@ -2,6 +2,7 @@ package jpp_test
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/tanishiking/jpp"
@@ -344,3 +345,137 @@ func TestPretty_Num(t *testing.T) {
 		t.Errorf("expected: %v, actual: %v", orig, actual)
 	}
 }
+
+func TestFormat_NumLiterals(t *testing.T) {
+	// Numbers must come back unchanged, or --write would rewrite them.
+	orig := `[12345678901234567891, 1.50, 1e400, -0, 1E+2, 0.000000000000000000001]`
+	actual, err := jpp.Format(orig, jpp.Options{Indent: "  ", Width: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if orig != actual {
+		t.Errorf("expected: %v, actual: %v", orig, actual)
+	}
+}
+
+type tagStyler struct{}
+
+func (tagStyler) Style(kind jpp.TokenKind, path jpp.Path, text string) (string, int) {
+	if kind == jpp.PunctuationToken {
+		return text, len(text)
+	}
+	return fmt.Sprintf("<%v %v>%v</%v>", kind, path, text, kind), len([]rune(text))
+}
+
+func TestFormat_Styler(t *testing.T) {
+	jsonStr := `{"foo": [1, null], "bar": {"baz": true}}`
+	actual, _ := jpp.Format(jsonStr, jpp.Options{Indent: "  ", Width: 40, Styler: tagStyler{}})
+	expected := `{
+  <key $.foo>"foo"</key>: [<number $.foo[0]>1</number>, <null $.foo[1]>null</null>],
+  <key $.bar>"bar"</key>: {<key $.bar.baz>"baz"</key>: <bool $.bar.baz>true</bool>}
+}`
+	if actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestPretty_EscapedString(t *testing.T) {
+	orig := `{"quote\"d": "line\nbreak", "tag": "<a>", "unicode": "あ"}`
+	actual, _ := jpp.Pretty(orig, "  ", 100, nil)
+	expected := `{"quote\"d": "line\nbreak", "tag": "<a>", "unicode": "あ"}`
+	if actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestPath(t *testing.T) {
+	path := jpp.Path{"items", 3, "a/b", "with space"}
+	if actual := path.String(); actual != `$.items[3]["a/b"]["with space"]` {
+		t.Errorf("actual: %v", actual)
+	}
+	if actual := path.Pointer(); actual != `/items/3/a~1b/with space` {
+		t.Errorf("actual: %v", actual)
+	}
+}
+
+func TestFormat_Concurrent(t *testing.T) {
+	jsonStr := `{"numbers": [[1,2,3,4,5], [6,7,8,9,10]]}`
+	expected := map[int]string{}
+	for _, w := range []int{10, 20, 100} {
+		expected[w], _ = jpp.Format(jsonStr, jpp.Options{Indent: "  ", Width: w})
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		w := []int{10, 20, 100}[i%3]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			actual, _ := jpp.Format(jsonStr, jpp.Options{Indent: "  ", Width: w})
+			if actual != expected[w] {
+				t.Errorf("width %v: expected: %v, actual: %v", w, expected[w], actual)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFormat_SortKeys(t *testing.T) {
+	jsonStr := `{"b": {"d": 1, "c": 2}, "a": [{"z": 1, "y": 2}], "c": {}}`
+	actual, _ := jpp.Format(jsonStr, jpp.Options{Indent: "  ", Width: 100, SortKeys: true})
+	expected := `{
+  "a": [
+    {"y": 2, "z": 1}
+  ],
+  "b": {"c": 2, "d": 1},
+  "c": {}
+}`
+	if actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestFormat_LayoutExpanded(t *testing.T) {
+	jsonStr := `{"a": [1, 2], "b": {"c": null}, "d": [], "e": {}}`
+	actual, _ := jpp.Format(jsonStr, jpp.Options{Indent: "  ", Width: 100, Layout: jpp.LayoutExpanded})
+	expected := `{
+  "a": [
+    1,
+    2
+  ],
+  "b": {
+    "c": null
+  },
+  "d": [],
+  "e": {}
+}`
+	if actual != expected {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestFormat_TabWidth(t *testing.T) {
+	jsonStr := `{"a": {"b": [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]}}`
+	actual, _ := jpp.Format(jsonStr, jpp.Options{Indent: "\t", Width: 40})
+	expected := "{\n" +
+		"\t\"a\": {\n" +
+		"\t\t\"b\": [\n" +
+		"\t\t\t1, 2, 3, 4, 5,\n" +
+		"\t\t\t6, 7, 8, 9, 10\n" +
+		"\t\t]\n" +
+		"\t}\n" +
+		"}"
+	if actual != expected {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+
+	actual, _ = jpp.Format(jsonStr, jpp.Options{Indent: "\t", Width: 40, TabWidth: 4})
+	expected = "{\n" +
+		"\t\"a\": {\n" +
+		"\t\t\"b\": [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]\n" +
+		"\t}\n" +
+		"}"
+	if actual != expected {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+}
@@ -0,0 +1,176 @@
+package jpp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+)
+
+// TextEdit replaces the bytes from Start to End of the source with NewText.
+type TextEdit struct {
+	Start   int
+	End     int
+	NewText string
+}
+
+// FormatRange formats only the smallest JSON value in jsonStr that encloses
+// the bytes from start to end, keeping the indentation of the line where
+// the value starts. The top-level value is formatted if no value encloses them.
+//
+// It returns the smallest edit to apply to jsonStr, so the rest of jsonStr
+// is left untouched. The edit is empty if the value is already formatted.
+func FormatRange(jsonStr string, start, end int, opts Options) (TextEdit, error) {
+	if start < 0 || end < start || end > len(jsonStr) {
+		return TextEdit{}, fmt.Errorf("invalid range %v-%v of %v bytes", start, end, len(jsonStr))
+	}
+	if !gjson.Valid(jsonStr) {
+		return TextEdit{}, errors.New("parse error: Invalid json input")
+	}
+	sc := &spanScanner{src: jsonStr}
+	// The range is moved into the top-level value, out of the whitespace
+	// around it such as the final newline.
+	top := Span{Start: sc.skipSpace(0), End: len(strings.TrimRight(jsonStr, " \t\r\n"))}
+	sc.start, sc.end = clamp(start, top), clamp(end, top)
+	sc.value(top.Start, Path{})
+	if !sc.found {
+		sc.start, sc.end = top.Start, top.End
+		sc.value(top.Start, Path{})
+	}
+
+	pr := newPrinter(opts)
+	pr.prefix = lineIndent(jsonStr, sc.best.Start)
+	var b bytes.Buffer
+	pr.prettyRec(&b, 0, sc.bestPath, gjson.Parse(jsonStr[sc.best.Start:sc.best.End]))
+	return MinimalEdit(jsonStr[sc.best.Start:sc.best.End], b.String(), sc.best.Start), nil
+}
+
+// clamp returns the offset i moved into the span.
+func clamp(i int, span Span) int {
+	if i < span.Start {
+		return span.Start
+	}
+	if i > span.End {
+		return span.End
+	}
+	return i
+}
+
+// lineIndent returns the leading whitespace of the line containing offset.
+func lineIndent(src string, offset int) string {
+	lineStart := offset
+	for lineStart > 0 && src[lineStart-1] != '\n' {
+		lineStart--
+	}
+	i := lineStart
+	for i < offset && (src[i] == ' ' || src[i] == '\t') {
+		i++
+	}
+	return src[lineStart:i]
+}
+
+// MinimalEdit returns the edit that replaces only the differing part of
+// oldText, which starts at offset in the source, to turn it into newText.
+// The edit is empty if they are equal.
+func MinimalEdit(oldText, newText string, offset int) TextEdit {
+	prefix := 0
+	for prefix < len(oldText) && prefix < len(newText) && oldText[prefix] == newText[prefix] {
+		prefix++
+	}
+	for prefix > 0 && prefix < len(oldText) && !utf8.RuneStart(oldText[prefix]) {
+		prefix--
+	}
+	suffix := 0
+	for suffix < len(oldText)-prefix && suffix < len(newText)-prefix &&
+		oldText[len(oldText)-1-suffix] == newText[len(newText)-1-suffix] {
+		suffix++
+	}
+	for suffix > 0 && !utf8.RuneStart(oldText[len(oldText)-suffix]) {
+		suffix--
+	}
+	return TextEdit{
+		Start:   offset + prefix,
+		End:     offset + len(oldText) - suffix,
+		NewText: newText[prefix : len(newText)-suffix],
+	}
+}
+
+// spanScanner finds the smallest value that encloses the range from start
+// to end in the valid JSON src.
+type spanScanner struct {
+	src        string
+	start, end int
+	found      bool
+	best       Span
+	bestPath   Path
+}
+
+func (sc *spanScanner) skipSpace(i int) int {
+	for i < len(sc.src) {
+		switch sc.src[i] {
+		case ' ', '\t', '\n', '\r':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// value scans the value at i and returns the offset after it.
+func (sc *spanScanner) value(i int, path Path) int {
+	start := i
+	switch sc.src[i] {
+	case '[':
+		i = sc.skipSpace(i + 1)
+		for n := 0; sc.src[i] != ']'; n++ {
+			i = sc.skipSpace(sc.value(i, path.Append(n)))
+			if sc.src[i] == ',' {
+				i = sc.skipSpace(i + 1)
+			}
+		}
+		i++
+	case '{':
+		i = sc.skipSpace(i + 1)
+		for sc.src[i] != '}' {
+			keyEnd := sc.str(i)
+			var key string
+			json.Unmarshal([]byte(sc.src[i:keyEnd]), &key)
+			i = sc.skipSpace(keyEnd)
+			// Skip the colon.
+			i = sc.skipSpace(i + 1)
+			i = sc.skipSpace(sc.value(i, path.Append(key)))
+			if sc.src[i] == ',' {
+				i = sc.skipSpace(i + 1)
+			}
+		}
+		i++
+	case '"':
+		i = sc.str(i)
+	default:
+		for i < len(sc.src) && strings.IndexByte(",]} \t\r\n", sc.src[i]) < 0 {
+			i++
+		}
+	}
+	// Children are scanned first, so an enclosing parent is never smaller.
+	if start <= sc.start && sc.end <= i && (!sc.found || i-start < sc.best.End-sc.best.Start) {
+		sc.found = true
+		sc.best = Span{Start: start, End: i}
+		sc.bestPath = path
+	}
+	return i
+}
+
+// str returns the offset after the string at i.
+func (sc *spanScanner) str(i int) int {
+	for i++; sc.src[i] != '"'; i++ {
+		if sc.src[i] == '\\' {
+			i++
+		}
+	}
+	return i + 1
+}
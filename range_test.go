@@ -0,0 +1,114 @@
+package jpp_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tanishiking/jpp"
+)
+
+func TestFormatRange(t *testing.T) {
+	src := `{
+    "keep":   [1,2],
+    "a": {"b": [1,2,3],   "c": "ç"},
+    "list": [
+        {"x":1}
+    ]
+}`
+	tests := []struct {
+		name     string
+		selected string
+		expected string
+	}{
+		{"whole inner object", `{"b": [1,2,3],   "c": "ç"}`, `{
+    "keep":   [1,2],
+    "a": {
+        "b": [1, 2, 3],
+        "c": "ç"
+    },
+    "list": [
+        {"x":1}
+    ]
+}`},
+		{"inside of an array", `2,3`, `{
+    "keep":   [1,2],
+    "a": {"b": [1, 2, 3],   "c": "ç"},
+    "list": [
+        {"x":1}
+    ]
+}`},
+		{"key of a member", `"c"`, `{
+    "keep":   [1,2],
+    "a": {
+        "b": [1, 2, 3],
+        "c": "ç"
+    },
+    "list": [
+        {"x":1}
+    ]
+}`},
+		{"nested on its own line", `{"x":1}`, `{
+    "keep":   [1,2],
+    "a": {"b": [1,2,3],   "c": "ç"},
+    "list": [
+        {"x": 1}
+    ]
+}`},
+	}
+	opts := jpp.Options{Indent: "    ", Width: 80}
+	for _, test := range tests {
+		start := strings.Index(src, test.selected)
+		edit, err := jpp.FormatRange(src, start, start+len(test.selected), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := src[:edit.Start] + edit.NewText + src[edit.End:]
+		if actual != test.expected {
+			t.Errorf("%v: got\n%v\nwant\n%v", test.name, actual, test.expected)
+		}
+	}
+}
+
+func TestFormatRangeIndentation(t *testing.T) {
+	src := "[\n\t{\"a\": [1, 2, 3, 4, 5, 6, 7, 8, 9, 10],\n\t\"b\": null}\n]"
+	start := strings.Index(src, "{")
+	edit, err := jpp.FormatRange(src, start, start, jpp.Options{Indent: "  ", Width: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := jpp.TextEdit{Start: start + 1, End: len(src) - 3, NewText: `
+	  "a": [
+	    1, 2, 3,
+	    4, 5, 6,
+	    7, 8, 9,
+	    10
+	  ],
+	  "b": null
+	`}
+	if edit != expected {
+		t.Errorf("got %#v, want %#v", edit, expected)
+	}
+
+	edit, err = jpp.FormatRange(`[1, 2]`, 1, 1, jpp.Options{Indent: "  ", Width: 80})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edit.Start != edit.End || edit.NewText != "" {
+		t.Errorf("got %#v for a formatted value, want an empty edit", edit)
+	}
+
+	src = "{\"a\":1}\n"
+	for _, r := range [][2]int{{0, len(src)}, {3, len(src)}, {7, len(src)}, {len(src), len(src)}} {
+		edit, err = jpp.FormatRange(src, r[0], r[1], jpp.Options{Indent: "  ", Width: 80})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := src[:edit.Start] + edit.NewText + src[edit.End:]; actual != "{\"a\": 1}\n" {
+			t.Errorf("range %v: got %q", r, actual)
+		}
+	}
+
+	if _, err := jpp.FormatRange(`[1, 2]`, 3, 10, jpp.Options{}); err == nil {
+		t.Errorf("expected an error for a range out of the source")
+	}
+}
@@ -0,0 +1,182 @@
+package jpp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// RedactedValue is the string that replaces redacted values.
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactedKeys are the key patterns of DefaultRedaction.
+var DefaultRedactedKeys = []string{
+	"*password*",
+	"passwd",
+	"*secret*",
+	"*token*",
+	"*api_key*",
+	"*apikey*",
+	"*api-key*",
+	"*private_key*",
+	"authorization",
+	"cookie",
+	"set-cookie",
+}
+
+// CardNumberPattern is the value pattern of credit card numbers. The values
+// matching it are redacted only if they pass the Luhn check, so that other
+// numbers of the same length such as IDs and timestamps are kept.
+const CardNumberPattern = `^(?:\d[ -]?){12,18}\d$`
+
+// DefaultRedactedValues are the value patterns of DefaultRedaction,
+// which match JSON Web Tokens, credit card numbers and AWS access keys.
+var DefaultRedactedValues = []string{
+	`\beyJ[\w-]+\.eyJ[\w-]+\.[\w-]*`,
+	CardNumberPattern,
+	`\bAKIA[0-9A-Z]{16}\b`,
+}
+
+// Redaction selects the values to be replaced with RedactedValue.
+type Redaction struct {
+	keys   []*regexp.Regexp
+	paths  []*regexp.Regexp
+	values []valuePattern
+}
+
+// valuePattern is a regular expression of the values to redact, which
+// also need to pass the Luhn check if luhn is set.
+type valuePattern struct {
+	re   *regexp.Regexp
+	luhn bool
+}
+
+// NewRedaction returns a Redaction that selects the values whose keys match
+// one of the glob patterns in keys case-insensitively, such as `*token*`,
+// the values at one of paths, and the strings and numbers matching one of
+// the regular expressions in values, where CardNumberPattern also needs the
+// Luhn check.
+// A path is in dotted notation such as `$.users[*].email`, or a JSON
+// Pointer such as `/users/*/email`, where `*` matches any key or index.
+func NewRedaction(keys, paths, values []string) (*Redaction, error) {
+	r := &Redaction{}
+	for _, k := range keys {
+		pattern := strings.Replace(regexp.QuoteMeta(k), `\*`, `.*`, -1)
+		pattern = strings.Replace(pattern, `\?`, `.`, -1)
+		r.keys = append(r.keys, regexp.MustCompile(`(?i)^`+pattern+`$`))
+	}
+	for _, p := range paths {
+		re, err := pathPattern(p)
+		if err != nil {
+			return nil, err
+		}
+		r.paths = append(r.paths, re)
+	}
+	for _, v := range values {
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value pattern %q: %v", v, err)
+		}
+		r.values = append(r.values, valuePattern{re: re, luhn: v == CardNumberPattern})
+	}
+	return r, nil
+}
+
+// DefaultRedaction returns the Redaction of DefaultRedactedKeys and DefaultRedactedValues.
+func DefaultRedaction() *Redaction {
+	r, _ := NewRedaction(DefaultRedactedKeys, nil, DefaultRedactedValues)
+	return r
+}
+
+// pathPattern converts the path p with wildcards to the regular expression
+// matching Path.String or Path.Pointer.
+func pathPattern(p string) (*regexp.Regexp, error) {
+	var pattern string
+	switch {
+	case strings.HasPrefix(p, "/"):
+		elems := strings.Split(p[1:], "/")
+		for i, e := range elems {
+			if e == "*" {
+				elems[i] = `[^/]*`
+			} else {
+				elems[i] = regexp.QuoteMeta(e)
+			}
+		}
+		pattern = "/" + strings.Join(elems, "/")
+	case strings.HasPrefix(p, "$"):
+		pattern = regexp.QuoteMeta(p)
+		pattern = strings.Replace(pattern, `\[\*\]`, `\[\d+\]`, -1)
+		pattern = strings.Replace(pattern, `\.\*`, `(?:\.[^.\[]+|\["(?:[^"\\]|\\.)*"\])`, -1)
+	default:
+		return nil, fmt.Errorf("invalid path %q: must start with $ or /", p)
+	}
+	return regexp.Compile("^" + pattern + "$")
+}
+
+// selects reports whether the value j at path is redacted.
+func (r *Redaction) selects(path Path, j gjson.Result) bool {
+	if len(path) > 0 {
+		if key, ok := path[len(path)-1].(string); ok {
+			for _, re := range r.keys {
+				if re.MatchString(key) {
+					return true
+				}
+			}
+		}
+	}
+	if len(r.paths) > 0 {
+		dotted, pointer := path.String(), path.Pointer()
+		for _, re := range r.paths {
+			if re.MatchString(dotted) || re.MatchString(pointer) {
+				return true
+			}
+		}
+	}
+	var text string
+	switch j.Type {
+	case gjson.String:
+		text = j.Str
+	case gjson.Number:
+		text = j.Raw
+	default:
+		return false
+	}
+	for _, v := range r.values {
+		if v.re.MatchString(text) && (!v.luhn || luhn(text)) {
+			return true
+		}
+	}
+	return false
+}
+
+// luhn reports whether the digits in s pass the Luhn check of card numbers.
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		d := int(s[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// redact returns j replaced with RedactedValue if it is selected.
+// The replacement keeps Raw and Index of j, so that source maps still point
+// to the value in the input, so Raw must not be printed.
+func (pr *printer) redact(path Path, j gjson.Result) gjson.Result {
+	if pr.redaction == nil || !pr.redaction.selects(path, j) {
+		return j
+	}
+	return gjson.Result{Type: gjson.String, Str: RedactedValue, Raw: j.Raw, Index: j.Index}
+}
@@ -0,0 +1,84 @@
+package jpp_test
+
+import (
+	"testing"
+
+	"github.com/tanishiking/jpp"
+)
+
+func TestRedaction(t *testing.T) {
+	jsonStr := `{"user": "a", "Password": "hunter2", "auth": {"api_token": {"v": 1}, "jwt": "eyJhbGciOi.eyJzdWIiOiIx.sig"}, "card": "4111 1111 1111 1111", "order": "4111111111111112", "card_no": 4111111111111111, "ts": 1700000000000, "emails": ["a@x", "b@y"], "note": "ok"}`
+	tests := []struct {
+		redaction *jpp.Redaction
+		expected  string
+	}{
+		{jpp.DefaultRedaction(), `{
+  "user": "a",
+  "Password": "[REDACTED]",
+  "auth": {"api_token": "[REDACTED]", "jwt": "[REDACTED]"},
+  "card": "[REDACTED]",
+  "order": "4111111111111112",
+  "card_no": "[REDACTED]",
+  "ts": 1700000000000,
+  "emails": ["a@x", "b@y"],
+  "note": "ok"
+}`},
+		{mustRedaction(t, []string{"no?e"}, []string{"$.emails[*]", "/auth/*"}, []string{`^a$`}), `{
+  "user": "[REDACTED]",
+  "Password": "hunter2",
+  "auth": {"api_token": "[REDACTED]", "jwt": "[REDACTED]"},
+  "card": "4111 1111 1111 1111",
+  "order": "4111111111111112",
+  "card_no": 4111111111111111,
+  "ts": 1700000000000,
+  "emails": ["[REDACTED]", "[REDACTED]"],
+  "note": "[REDACTED]"
+}`},
+	}
+	for _, test := range tests {
+		actual, err := jpp.Format(jsonStr, jpp.Options{Indent: "  ", Width: 70, Redaction: test.redaction})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != test.expected {
+			t.Errorf("got\n%v\nwant\n%v", actual, test.expected)
+		}
+	}
+
+	gron, _ := jpp.Gron(`{"token": [1]}`, jpp.Options{Redaction: jpp.DefaultRedaction()})
+	if expected := "json = {};\njson.token = \"[REDACTED]\";"; gron != expected {
+		t.Errorf("got %q, want %q", gron, expected)
+	}
+
+	if _, err := jpp.NewRedaction(nil, []string{"users"}, nil); err == nil {
+		t.Error("expected an error for a path without $ or /")
+	}
+	if _, err := jpp.NewRedaction(nil, nil, []string{"("}); err == nil {
+		t.Error("expected an error for an invalid regular expression")
+	}
+}
+
+func TestRedaction_sourceMap(t *testing.T) {
+	jsonStr := `{"secret": {"a": 1}, "b": 2}`
+	res, mappings, err := jpp.FormatWithSourceMap(jsonStr, jpp.Options{Indent: "  ", Width: 80, Redaction: jpp.DefaultRedaction()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range mappings {
+		if m.Path.String() == "$.secret" && m.Kind == jpp.StringToken {
+			if out, in := res[m.Output.Start:m.Output.End], jsonStr[m.Input.Start:m.Input.End]; out != `"[REDACTED]"` || in != `{"a": 1}` {
+				t.Errorf("got %v mapped to %v", out, in)
+			}
+			return
+		}
+	}
+	t.Errorf("the redacted value is not mapped: %v", mappings)
+}
+
+func mustRedaction(t *testing.T, keys, paths, values []string) *jpp.Redaction {
+	r, err := jpp.NewRedaction(keys, paths, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
@@ -0,0 +1,166 @@
+package jpp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// textStyle is the style of text decoded from ANSI SGR parameters.
+// It is used to render colors of a ColorScheme in formats other than
+// the terminal, such as HTML and SVG.
+type textStyle struct {
+	// foreground and background are colors in "#rrggbb", or empty
+	// for the default color.
+	foreground string
+	background string
+	bold       bool
+	dim        bool
+	italic     bool
+	underline  bool
+}
+
+var sgrPattern = regexp.MustCompile("\x1b\\[([0-9;]*)m")
+
+// ansiPalette is the 16 basic colors of xterm.
+var ansiPalette = [16]string{
+	"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+	"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// styleOf decodes the SGR sequences color emits for a sample text.
+func styleOf(color ColoredFormat) textStyle {
+	var s textStyle
+	if color == nil {
+		return s
+	}
+	colored := color("%s", "x")
+	if i := strings.Index(colored, "x"); i >= 0 {
+		colored = colored[:i]
+	}
+	for _, m := range sgrPattern.FindAllStringSubmatch(colored, -1) {
+		s.apply(m[1])
+	}
+	return s
+}
+
+func (s *textStyle) apply(params string) {
+	var codes []int
+	for _, param := range strings.Split(params, ";") {
+		code, err := strconv.Atoi(param)
+		if err != nil {
+			code = 0
+		}
+		codes = append(codes, code)
+	}
+	for i := 0; i < len(codes); i++ {
+		code := codes[i]
+		switch {
+		case code == 0:
+			*s = textStyle{}
+		case code == 1:
+			s.bold = true
+		case code == 2:
+			s.dim = true
+		case code == 3:
+			s.italic = true
+		case code == 4:
+			s.underline = true
+		case code == 22:
+			s.bold, s.dim = false, false
+		case code == 23:
+			s.italic = false
+		case code == 24:
+			s.underline = false
+		case 30 <= code && code <= 37:
+			s.foreground = ansiPalette[code-30]
+		case 90 <= code && code <= 97:
+			s.foreground = ansiPalette[code-90+8]
+		case code == 39:
+			s.foreground = ""
+		case 40 <= code && code <= 47:
+			s.background = ansiPalette[code-40]
+		case 100 <= code && code <= 107:
+			s.background = ansiPalette[code-100+8]
+		case code == 49:
+			s.background = ""
+		case code == 38 || code == 48:
+			color, n := extendedColor(codes[i+1:])
+			i += n
+			if code == 38 {
+				s.foreground = color
+			} else {
+				s.background = color
+			}
+		}
+	}
+}
+
+// extendedColor decodes the parameters following 38 or 48, which are
+// either "5;n" (256 colors) or "2;r;g;b" (true color).
+// It returns the color and the number of parameters consumed.
+func extendedColor(codes []int) (string, int) {
+	if len(codes) >= 2 && codes[0] == 5 {
+		return color256(codes[1]), 2
+	}
+	if len(codes) >= 4 && codes[0] == 2 {
+		return fmt.Sprintf("#%02x%02x%02x", codes[1]&0xff, codes[2]&0xff, codes[3]&0xff), 4
+	}
+	return "", len(codes)
+}
+
+func color256(n int) string {
+	switch {
+	case n < 0 || n > 255:
+		return ""
+	case n < 16:
+		return ansiPalette[n]
+	case n < 232:
+		n -= 16
+		levels := [6]int{0, 95, 135, 175, 215, 255}
+		return fmt.Sprintf("#%02x%02x%02x", levels[n/36], levels[n/6%6], levels[n%6])
+	default:
+		gray := 8 + (n-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+	}
+}
+
+// css returns the declarations of the style for HTML, such as "color: #cd0000;".
+func (s textStyle) css() string {
+	var decls []string
+	if s.foreground != "" {
+		decls = append(decls, "color: "+s.foreground+";")
+	}
+	if s.background != "" {
+		decls = append(decls, "background-color: "+s.background+";")
+	}
+	return strings.Join(append(decls, s.fontDecls()...), " ")
+}
+
+// svgCSS returns the declarations of the style for SVG, such as "fill: #cd0000;".
+// Background colors are not supported in SVG text.
+func (s textStyle) svgCSS() string {
+	var decls []string
+	if s.foreground != "" {
+		decls = append(decls, "fill: "+s.foreground+";")
+	}
+	return strings.Join(append(decls, s.fontDecls()...), " ")
+}
+
+func (s textStyle) fontDecls() []string {
+	var decls []string
+	if s.bold {
+		decls = append(decls, "font-weight: bold;")
+	}
+	if s.dim {
+		decls = append(decls, "opacity: 0.6;")
+	}
+	if s.italic {
+		decls = append(decls, "font-style: italic;")
+	}
+	if s.underline {
+		decls = append(decls, "text-decoration: underline;")
+	}
+	return decls
+}
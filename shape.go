@@ -0,0 +1,168 @@
+package jpp
+
+import (
+	"errors"
+	"strconv"
+
+	p "github.com/tanishiking/prettier"
+	"github.com/tidwall/gjson"
+)
+
+// ShapeOptions configures the output of FormatShape.
+type ShapeOptions struct {
+	// Counts prints the number of elements after each array, such as `×250`.
+	// The elements of arrays merged into one shape are counted together.
+	Counts bool
+}
+
+// FormatShape prints the structure of jsonStr, where values are replaced by
+// their types and the elements of each array are merged into one shape,
+// such as `{"users": [{"id": number, "email": string|null}]}`.
+// Keys missing in some of the merged objects are marked with `?`.
+// The shape is laid out within opts.Width like Format.
+func FormatShape(jsonStr string, opts Options, shapeOpts ShapeOptions) (string, error) {
+	if !gjson.Valid(jsonStr) {
+		return "", errors.New("parse error: Invalid json input")
+	}
+	pr := newPrinter(opts)
+	pr.expandStrings = 0
+	s := &shape{}
+	s.merge(pr, Path{}, gjson.Parse(jsonStr))
+	return pr.render(pr.shapeDoc(s, shapeOpts), 0), nil
+}
+
+// shape is the union of the types of the values merged into it.
+type shape struct {
+	// path is the path to the first value merged into the shape.
+	path   Path
+	count  int
+	kinds  map[TokenKind]bool
+	object *objectShape
+	array  *arrayShape
+}
+
+type objectShape struct {
+	count   int
+	keys    []string
+	members map[string]*shape
+}
+
+type arrayShape struct {
+	// count is the number of the elements.
+	count int
+	elem  *shape
+}
+
+func (s *shape) merge(pr *printer, path Path, j gjson.Result) {
+	j = pr.redact(path, j)
+	if s.count == 0 {
+		s.path = path
+	}
+	s.count++
+	switch {
+	case j.IsArray():
+		if s.array == nil {
+			s.array = &arrayShape{}
+		}
+		for i, item := range j.Array() {
+			if s.array.elem == nil {
+				s.array.elem = &shape{}
+			}
+			s.array.elem.merge(pr, path.Append(i), item)
+			s.array.count++
+		}
+	case j.IsObject():
+		if s.object == nil {
+			s.object = &objectShape{members: map[string]*shape{}}
+		}
+		s.object.count++
+		j.ForEach(func(k, v gjson.Result) bool {
+			member, ok := s.object.members[k.Str]
+			if !ok {
+				member = &shape{}
+				s.object.keys = append(s.object.keys, k.Str)
+				s.object.members[k.Str] = member
+			}
+			member.merge(pr, path.Append(k.Str), v)
+			return true
+		})
+	default:
+		if s.kinds == nil {
+			s.kinds = map[TokenKind]bool{}
+		}
+		kind, _ := scalarToken(j)
+		s.kinds[kind] = true
+	}
+}
+
+// scalarTypes are the names of the scalar types in the printed order.
+var scalarTypes = []struct {
+	kind TokenKind
+	name string
+}{
+	{BoolToken, "boolean"},
+	{NumberToken, "number"},
+	{StringToken, "string"},
+	{NullToken, "null"},
+}
+
+// shapeDoc converts s to p.Doc, joining the types with `|`.
+func (pr *printer) shapeDoc(s *shape, shapeOpts ShapeOptions) p.Doc {
+	var ds []p.Doc
+	if s.object != nil {
+		ds = append(ds, pr.objectShapeDoc(s, shapeOpts))
+	}
+	if s.array != nil {
+		ds = append(ds, pr.arrayShapeDoc(s, shapeOpts))
+	}
+	for _, t := range scalarTypes {
+		if s.kinds[t.kind] {
+			ds = append(ds, pr.token(t.kind, s.path, t.name))
+		}
+	}
+	return p.Intercalate(pr.punct(s.path, "|"), ds)
+}
+
+func (pr *printer) objectShapeDoc(s *shape, shapeOpts ShapeOptions) p.Doc {
+	o := s.object
+	if len(o.keys) == 0 {
+		return pr.punct(s.path, "{}")
+	}
+	members := make([]p.Doc, 0, len(o.keys))
+	for _, key := range o.keys {
+		member := o.members[key]
+		ds := []p.Doc{pr.token(KeyToken, member.path, encodeString(key))}
+		if member.count < o.count {
+			ds = append(ds, pr.punct(s.path, "?"))
+		}
+		ds = append(ds, pr.punct(s.path, ":"), p.Text(" "), pr.shapeDoc(member, shapeOpts))
+		members = append(members, p.Concat(ds))
+	}
+	// Either all members fit in a line, or each of them is on its own line.
+	sep := p.Concat([]p.Doc{pr.punct(s.path, ","), p.Line()})
+	return p.TightBracketBy(
+		pr.punct(s.path, "{"),
+		pr.punct(s.path, "}"),
+		p.Intercalate(sep, members),
+		uint(pr.nestWidth(0)),
+	)
+}
+
+func (pr *printer) arrayShapeDoc(s *shape, shapeOpts ShapeOptions) p.Doc {
+	a := s.array
+	var doc p.Doc
+	if a.elem == nil {
+		doc = pr.punct(s.path, "[]")
+	} else {
+		doc = p.TightBracketBy(
+			pr.punct(s.path, "["),
+			pr.punct(s.path, "]"),
+			pr.shapeDoc(a.elem, shapeOpts),
+			uint(pr.nestWidth(0)),
+		)
+	}
+	if shapeOpts.Counts {
+		doc = p.Concat([]p.Doc{doc, p.Text(" "), pr.punct(s.path, "×"+strconv.Itoa(a.count))})
+	}
+	return doc
+}
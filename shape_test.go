@@ -0,0 +1,47 @@
+package jpp_test
+
+import (
+	"testing"
+
+	"github.com/tanishiking/jpp"
+)
+
+func TestFormatShape(t *testing.T) {
+	jsonStr := `{"users": [{"id": 1, "email": "a"}, {"id": 2, "email": null, "admin": true}], "tags": [], "meta": {}}`
+	tests := []struct {
+		width    int
+		counts   bool
+		expected string
+	}{
+		{120, true, `{"users": [{"id": number, "email": string|null, "admin"?: boolean}] ×2, "tags": [] ×0, "meta": {}}`},
+		{40, false, `{
+  "users": [
+    {
+      "id": number,
+      "email": string|null,
+      "admin"?: boolean
+    }
+  ],
+  "tags": [],
+  "meta": {}
+}`},
+	}
+	for _, test := range tests {
+		actual, err := jpp.FormatShape(jsonStr, jpp.Options{Indent: "  ", Width: test.width}, jpp.ShapeOptions{Counts: test.counts})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != test.expected {
+			t.Errorf("width %v: got\n%v\nwant\n%v", test.width, actual, test.expected)
+		}
+	}
+
+	actual, _ := jpp.FormatShape(`[1, "a", [2], {"a": 1}]`, jpp.Options{Indent: "\t", Width: 80}, jpp.ShapeOptions{})
+	if expected := `[{"a": number}|[number]|number|string]`; actual != expected {
+		t.Errorf("got %v, want %v", actual, expected)
+	}
+	actual, _ = jpp.FormatShape(`{"a": {"b": {"c": 1}}}`, jpp.Options{Indent: "\t", Width: 10}, jpp.ShapeOptions{})
+	if expected := "{\n\t\"a\": {\n\t\t\"b\": {\n\t\t\t\"c\": number\n\t\t}\n\t}\n}"; actual != expected {
+		t.Errorf("got %q, want %q", actual, expected)
+	}
+}
@@ -0,0 +1,132 @@
+package jpp
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	p "github.com/tanishiking/prettier"
+	"github.com/tidwall/gjson"
+)
+
+// Span is a range of bytes in a text.
+type Span struct {
+	Start int
+	End   int
+}
+
+// Mapping maps a token of the formatted output to the input it came from.
+type Mapping struct {
+	Kind TokenKind
+	// Path is the path to the value the token belongs to.
+	Path Path
+	// Text is the text of the token without its decoration.
+	Text string
+	// Output is the span of the token in the output, including its decoration.
+	Output Span
+	// Input is the span of the token in the input.
+	// Commas missing in the input, such as the ones after the members moved
+	// by SortKeys, map to the empty span right after the value.
+	Input Span
+}
+
+// FormatWithSourceMap formats jsonStr like Format, and also returns the
+// mappings of the tokens in the output in the order they appear.
+func FormatWithSourceMap(jsonStr string, opts Options) (string, []Mapping, error) {
+	if !gjson.Valid(jsonStr) {
+		return "", nil, errors.New("parse error: Invalid json input")
+	}
+	root := gjson.Parse(jsonStr)
+	root.Index = len(jsonStr) - len(strings.TrimLeft(jsonStr, " \t\r\n"))
+
+	pr := newPrinter(opts)
+	pr.sourceMap = true
+	pr.src = jsonStr
+	var b bytes.Buffer
+	pr.prettyRec(&b, 0, Path{}, root)
+	return b.String(), pr.mappings, nil
+}
+
+// docMark marks the tokens in the docs for source maps. It doesn't count
+// in the length of the tokens, so the layout is the same without it.
+const docMark = '\x00'
+
+// writeMapped writes the token like writeToken, and maps it to the span
+// in the input if the source map is requested.
+func (pr *printer) writeMapped(dst *bytes.Buffer, kind TokenKind, path Path, text string, in Span) {
+	start := dst.Len()
+	pr.writeToken(dst, kind, path, text)
+	if pr.sourceMap {
+		pr.mappings = append(pr.mappings, pr.mapping(kind, path, text, in, Span{Start: start, End: dst.Len()}))
+	}
+}
+
+// mappedToken converts the token to p.Doc like token, and maps it to the
+// span in the input if the source map is requested. The tokens must be
+// converted in the order they appear in the doc.
+func (pr *printer) mappedToken(kind TokenKind, path Path, text string, in Span) p.Doc {
+	if !pr.sourceMap {
+		return pr.token(kind, path, text)
+	}
+	styled, length := pr.styler.Style(kind, path, text)
+	// The output span is relative to the token until writeDoc finds it.
+	pr.docTokens = append(pr.docTokens, pr.mapping(kind, path, text, in, Span{End: len(styled)}))
+	return p.TextWithLength(string(docMark)+styled, length)
+}
+
+// writeDoc writes the doc of a container at depth, and records the
+// mappings of the tokens in it at the marks.
+func (pr *printer) writeDoc(dst *bytes.Buffer, doc p.Doc, depth int) {
+	rendered := pr.render(doc, depth)
+	for _, m := range pr.docTokens {
+		i := strings.IndexByte(rendered, docMark)
+		if i < 0 {
+			break
+		}
+		dst.WriteString(rendered[:i])
+		rendered = rendered[i+1:]
+		m.Output.Start += dst.Len()
+		m.Output.End += dst.Len()
+		pr.mappings = append(pr.mappings, m)
+	}
+	pr.docTokens = nil
+	dst.WriteString(rendered)
+}
+
+func (pr *printer) mapping(kind TokenKind, path Path, text string, in, out Span) Mapping {
+	if pr.within != nil {
+		in = *pr.within
+	}
+	return Mapping{Kind: kind, Path: path, Text: text, Output: out, Input: in}
+}
+
+// after returns the span of c following the value j in the input,
+// or the empty span after j if c doesn't follow it.
+func (pr *printer) after(j gjson.Result, c byte) Span {
+	end := j.Index + len(j.Raw)
+	i := end
+	for i < len(pr.src) && strings.IndexByte(" \t\r\n", pr.src[i]) >= 0 {
+		i++
+	}
+	if i < len(pr.src) && pr.src[i] == c {
+		return Span{Start: i, End: i + 1}
+	}
+	return Span{Start: end, End: end}
+}
+
+// valueSpan returns the span of the value j in the input.
+func valueSpan(j gjson.Result) Span {
+	return Span{Start: j.Index, End: j.Index + len(j.Raw)}
+}
+
+// opening returns the span of the opening bracket of the container j.
+func opening(j gjson.Result) Span {
+	return Span{Start: j.Index, End: j.Index + 1}
+}
+
+// closing returns the span of the closing bracket of the container j.
+// The raw of the top-level value contains the trailing whitespace.
+func closing(j gjson.Result) Span {
+	end := j.Index + len(strings.TrimRight(j.Raw, " \t\r\n"))
+	return Span{Start: end - 1, End: end}
+}
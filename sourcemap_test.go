@@ -0,0 +1,58 @@
+package jpp_test
+
+import (
+	"testing"
+
+	"github.com/tanishiking/jpp"
+)
+
+func TestFormatWithSourceMap(t *testing.T) {
+	src := ` {"b": [1,  2, {"c":null}], "a" :"x" }
+`
+	for _, opts := range []jpp.Options{
+		{Indent: "  ", Width: 80},
+		{Indent: "\t", Width: 10, Layout: jpp.LayoutExpanded},
+		{Indent: "  ", Width: 80, Styler: jpp.DefaultScheme},
+	} {
+		res, mappings, err := jpp.FormatWithSourceMap(src, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(mappings) != 19 {
+			t.Fatalf("got %v mappings, want 19", len(mappings))
+		}
+		for _, m := range mappings {
+			in := src[m.Input.Start:m.Input.End]
+			if m.Text != in {
+				t.Errorf("%v token at %v has text %q, want %q", m.Kind, m.Path, m.Text, in)
+			}
+			if opts.Styler == nil && res[m.Output.Start:m.Output.End] != in {
+				t.Errorf("%v token at %v is mapped to %q, want %q", m.Kind, m.Path, in, res[m.Output.Start:m.Output.End])
+			}
+		}
+		if m := mappings[11]; m.Kind != jpp.NullToken || m.Path.String() != `$.b[2].c` || m.Input != (jpp.Span{Start: 20, End: 24}) {
+			t.Errorf("got %+v for null", m)
+		}
+	}
+
+	_, mappings, err := jpp.FormatWithSourceMap(src, jpp.Options{Indent: "  ", Width: 80, SortKeys: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		text string
+		in   jpp.Span
+	}{
+		{`"a"`, jpp.Span{Start: 28, End: 31}},
+		{`:`, jpp.Span{Start: 32, End: 33}},
+		{`"x"`, jpp.Span{Start: 33, End: 36}},
+		// The comma after "a" doesn't exist in the input.
+		{`,`, jpp.Span{Start: 36, End: 36}},
+		{`"b"`, jpp.Span{Start: 2, End: 5}},
+	}
+	for i, e := range expected {
+		if m := mappings[i+1]; m.Text != e.text || m.Input != e.in {
+			t.Errorf("%v: got %+v, want %v", e.text, m, e.in)
+		}
+	}
+}
@@ -0,0 +1,483 @@
+package jpp
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// DiffOptions configures Diff.
+type DiffOptions struct {
+	// IgnoreKeyOrder doesn't report objects whose keys are only reordered.
+	IgnoreKeyOrder bool
+	// IDKey matches the elements of arrays that are objects by the value
+	// of this key, instead of by their equality and positions.
+	IDKey string
+	// Added, Removed and Changed decorate the lines of the differences.
+	// NoColor is used if they are nil.
+	Added   ColoredFormat
+	Removed ColoredFormat
+	Changed ColoredFormat
+}
+
+// Diff compares the documents a and b structurally, and renders the
+// differences in the layout of Format, where the lines are marked with
+// `+` for added, `-` for removed and `~` for changed values. Runs of
+// unchanged members and elements are collapsed into a line.
+// It also reports whether the documents differ, and renders nothing if not.
+func Diff(a, b string, opts Options, diffOpts DiffOptions) (string, bool, error) {
+	if !gjson.Valid(a) || !gjson.Valid(b) {
+		return "", false, errors.New("parse error: Invalid json input")
+	}
+	opts.ExpandStrings = 0
+	d := &differ{pr: newPrinter(opts), opts: diffOpts}
+	// The markers take two columns.
+	d.pr.width -= 2
+	plainOpts := opts
+	plainOpts.Styler = DefaultScheme
+	d.plain = newPrinter(plainOpts)
+	d.plain.width -= 2
+	for _, c := range []*ColoredFormat{&d.opts.Added, &d.opts.Removed, &d.opts.Changed} {
+		if *c == nil {
+			*c = NoColor
+		}
+	}
+
+	ja, jb := gjson.Parse(a), gjson.Parse(b)
+	if d.equal(Path{}, ja, jb) {
+		return "", false, nil
+	}
+	d.diff(0, Path{}, nil, ja, jb)
+	return strings.TrimSuffix(d.b.String(), "\n"), true, nil
+}
+
+type differ struct {
+	// pr prints unchanged lines, and plain prints changed lines
+	// which are decorated as a whole.
+	pr    *printer
+	plain *printer
+	opts  DiffOptions
+	b     strings.Builder
+}
+
+// line writes a line marked with marker.
+func (d *differ) line(marker string, color ColoredFormat, depth int, text string) {
+	line := marker + " " + strings.Repeat(d.pr.indent, depth) + text
+	if color != nil {
+		line = color("%s", line)
+	}
+	d.b.WriteString(line)
+	d.b.WriteString("\n")
+}
+
+// label returns the key followed by a colon, or "" for elements of arrays.
+func (d *differ) label(pr *printer, path Path, key *string) string {
+	if key == nil {
+		return ""
+	}
+	k, _ := pr.styler.Style(KeyToken, path, encodeString(*key))
+	colon, _ := pr.styler.Style(PunctuationToken, path[:len(path)-1], ":")
+	return k + colon + " "
+}
+
+// block writes the whole value j with marker.
+func (d *differ) block(marker string, color ColoredFormat, depth int, path Path, key *string, j gjson.Result) {
+	var buf bytes.Buffer
+	d.plain.prettyRec(&buf, depth, path, j)
+	for i, l := range strings.Split(buf.String(), "\n") {
+		if i == 0 {
+			d.line(marker, color, depth, d.label(d.plain, path, key)+l)
+		} else {
+			// The printer indents the following lines.
+			d.line(marker, color, 0, l)
+		}
+	}
+}
+
+func (d *differ) diff(depth int, path Path, key *string, a, b gjson.Result) {
+	a, b = d.pr.redact(path, a), d.pr.redact(path, b)
+	switch {
+	case a.IsObject() && b.IsObject():
+		d.diffObjects(depth, path, key, a, b)
+	case a.IsArray() && b.IsArray():
+		d.diffArrays(depth, path, key, a, b)
+	case a.Type != gjson.JSON && b.Type != gjson.JSON:
+		_, before := scalarToken(a)
+		_, after := scalarToken(b)
+		d.line("~", d.opts.Changed, depth, d.label(d.plain, path, key)+before+" → "+after)
+	default:
+		d.block("-", d.opts.Removed, depth, path, key, a)
+		d.block("+", d.opts.Added, depth, path, key, b)
+	}
+}
+
+// collapse writes the line for n unchanged members or elements.
+func (d *differ) collapse(depth int, n int, noun string) {
+	if n == 0 {
+		return
+	}
+	if n > 1 {
+		noun += "s"
+	}
+	d.line(" ", nil, depth, "… "+strconv.Itoa(n)+" unchanged "+noun)
+}
+
+func (d *differ) diffObjects(depth int, path Path, key *string, a, b gjson.Result) {
+	pa, pb := d.pr.members(path, a), d.pr.members(path, b)
+	inB := map[string]gjson.Result{}
+	for _, m := range pb {
+		inB[m.key.Str] = m.value
+	}
+	// Duplicated keys count once in the order of the keys.
+	inA := map[string]bool{}
+	var common []string
+	for _, m := range pa {
+		if inA[m.key.Str] {
+			continue
+		}
+		inA[m.key.Str] = true
+		if _, ok := inB[m.key.Str]; ok {
+			common = append(common, m.key.Str)
+		}
+	}
+	reordered := false
+	if !d.opts.IgnoreKeyOrder {
+		i := 0
+		seen := map[string]bool{}
+		for _, m := range pb {
+			if inA[m.key.Str] && !seen[m.key.Str] {
+				seen[m.key.Str] = true
+				if common[i] != m.key.Str {
+					reordered = true
+				}
+				i++
+			}
+		}
+	}
+
+	open, _ := d.pr.styler.Style(PunctuationToken, path, "{")
+	if reordered {
+		d.line("~", d.opts.Changed, depth, d.label(d.plain, path, key)+"{  (key order changed)")
+	} else {
+		d.line(" ", nil, depth, d.label(d.pr, path, key)+open)
+	}
+	unchanged := 0
+	for _, m := range pa {
+		k := m.key.Str
+		valuePath := path.Append(k)
+		after, ok := inB[k]
+		if ok && d.equal(valuePath, m.value, after) {
+			unchanged++
+			continue
+		}
+		d.collapse(depth+1, unchanged, "member")
+		unchanged = 0
+		if ok {
+			d.diff(depth+1, valuePath, &k, m.value, after)
+		} else {
+			d.block("-", d.opts.Removed, depth+1, valuePath, &k, m.value)
+		}
+	}
+	d.collapse(depth+1, unchanged, "member")
+	for _, m := range pb {
+		if !inA[m.key.Str] {
+			k := m.key.Str
+			d.block("+", d.opts.Added, depth+1, path.Append(k), &k, m.value)
+		}
+	}
+	closing, _ := d.pr.styler.Style(PunctuationToken, path, "}")
+	d.line(" ", nil, depth, closing)
+}
+
+// keys returns the canonical forms of the elements of an array at path,
+// and the keys to align them by, which are the IDs of the objects with
+// IDKey, and the forms of the others.
+func (d *differ) keys(path Path, items []gjson.Result) (forms, keys []string) {
+	forms = make([]string, len(items))
+	keys = make([]string, len(items))
+	for i, item := range items {
+		forms[i] = d.canonical(path.Append(i), item)
+		keys[i] = "=" + forms[i]
+		if d.opts.IDKey != "" && item.IsObject() {
+			if id := lookup(item, d.opts.IDKey); id.Exists() {
+				keys[i] = "#" + d.canonical(path.Append(i).Append(d.opts.IDKey), id)
+			}
+		}
+	}
+	return forms, keys
+}
+
+func (d *differ) diffArrays(depth int, path Path, key *string, a, b gjson.Result) {
+	xs, ys := values(d.pr.elements(path, a)), values(d.pr.elements(path, b))
+	fx, kx := d.keys(path, xs)
+	fy, ky := d.keys(path, ys)
+	// Align the elements by a shortest edit script between their keys.
+	var pairs [][2]int
+	alignKeys(kx, ky, 0, len(kx), 0, len(ky), &pairs)
+
+	open, _ := d.pr.styler.Style(PunctuationToken, path, "[")
+	d.line(" ", nil, depth, d.label(d.pr, path, key)+open)
+	unchanged := 0
+	var removed, added []int
+	// flush writes the unmatched elements between matched ones. Without
+	// IDKey, they are compared pairwise if they are of the same kind.
+	flush := func() {
+		if len(removed) == 0 && len(added) == 0 {
+			return
+		}
+		d.collapse(depth+1, unchanged, "element")
+		unchanged = 0
+		n := 0
+		if d.opts.IDKey == "" {
+			for n < len(removed) && n < len(added) && sameKind(xs[removed[n]], ys[added[n]]) {
+				n++
+			}
+		}
+		for k := 0; k < n; k++ {
+			d.diff(depth+1, path.Append(added[k]), nil, xs[removed[k]], ys[added[k]])
+		}
+		for _, i := range removed[n:] {
+			d.block("-", d.opts.Removed, depth+1, path.Append(i), nil, xs[i])
+		}
+		for _, j := range added[n:] {
+			d.block("+", d.opts.Added, depth+1, path.Append(j), nil, ys[j])
+		}
+		removed, added = nil, nil
+	}
+	// match writes the matched elements, which are changed if they have
+	// the same ID but different forms.
+	match := func(i, j int) {
+		flush()
+		if fx[i] == fy[j] {
+			unchanged++
+			return
+		}
+		d.collapse(depth+1, unchanged, "element")
+		unchanged = 0
+		d.diff(depth+1, path.Append(j), nil, xs[i], ys[j])
+	}
+	i, j := 0, 0
+	for _, p := range pairs {
+		for ; i < p[0]; i++ {
+			removed = append(removed, i)
+		}
+		for ; j < p[1]; j++ {
+			added = append(added, j)
+		}
+		match(i, j)
+		i++
+		j++
+	}
+	for ; i < len(xs); i++ {
+		removed = append(removed, i)
+	}
+	for ; j < len(ys); j++ {
+		added = append(added, j)
+	}
+	flush()
+	d.collapse(depth+1, unchanged, "element")
+	closing, _ := d.pr.styler.Style(PunctuationToken, path, "]")
+	d.line(" ", nil, depth, closing)
+}
+
+// alignKeys appends the pairs of the indices of the keys in a[aLo:aHi] and
+// b[bLo:bHi] kept by a shortest edit script between them to pairs. The
+// script is split at its middle snake, so that it takes linear space.
+func alignKeys(a, b []string, aLo, aHi, bLo, bHi int, pairs *[][2]int) {
+	for aLo < aHi && bLo < bHi && a[aLo] == b[bLo] {
+		*pairs = append(*pairs, [2]int{aLo, bLo})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && a[aHi-1-suffix] == b[bHi-1-suffix] {
+		suffix++
+	}
+	aHi -= suffix
+	bHi -= suffix
+
+	if aLo < aHi && bLo < bHi {
+		x, y, u, v := middleSnake(a[aLo:aHi], b[bLo:bHi])
+		alignKeys(a, b, aLo, aLo+x, bLo, bLo+y, pairs)
+		for i := 0; i < u-x; i++ {
+			*pairs = append(*pairs, [2]int{aLo + x + i, bLo + y + i})
+		}
+		alignKeys(a, b, aLo+u, aHi, bLo+v, bHi, pairs)
+	}
+
+	for i := 0; i < suffix; i++ {
+		*pairs = append(*pairs, [2]int{aHi + i, bHi + i})
+	}
+}
+
+// middleSnake returns the start (x, y) and the end (u, v) of the middle
+// snake of the shortest edit script from a to b, found by searching from
+// both ends at the same time.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	// forward[k] is the furthest x on the diagonal k = x - y from (0, 0), and
+	// backward[k] is the furthest x on the diagonal k of the reversed sequences.
+	forward := make([]int, 2*maxD+3)
+	backward := make([]int, 2*maxD+3)
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			// The diagonal k is the diagonal delta-k of the reversed sequences.
+			if rk := delta - k; odd && rk >= -(d-1) && rk <= d-1 && x+backward[offset+rk] >= n {
+				return startX, startY, x, y
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			if fk := delta - k; !odd && fk >= -d && fk <= d && x+forward[offset+fk] >= n {
+				return n - x, m - y, n - startX, m - startY
+			}
+		}
+	}
+	// Unreachable since the script has at most n+m edits.
+	return 0, 0, 0, 0
+}
+
+// values returns the values of the elements.
+func values(items []member) []gjson.Result {
+	vs := make([]gjson.Result, len(items))
+	for i, item := range items {
+		vs[i] = item.value
+	}
+	return vs
+}
+
+// lookup returns the value of key in the object j. Unlike Get, key is not
+// a path, so it may contain any characters.
+func lookup(j gjson.Result, key string) gjson.Result {
+	var v gjson.Result
+	j.ForEach(func(k, value gjson.Result) bool {
+		if k.Str == key {
+			v = value
+			return false
+		}
+		return true
+	})
+	return v
+}
+
+func sameKind(a, b gjson.Result) bool {
+	return a.IsObject() == b.IsObject() && a.IsArray() == b.IsArray()
+}
+
+// equal reports whether a and b at path are equal after the redaction,
+// where the order of keys matters unless IgnoreKeyOrder is set.
+func (d *differ) equal(path Path, a, b gjson.Result) bool {
+	return d.canonical(path, a) == d.canonical(path, b)
+}
+
+// canonical returns the compact JSON of j at path after the redaction,
+// which is the same for equal values: numbers are normalized, and keys
+// are sorted if IgnoreKeyOrder is set.
+func (d *differ) canonical(path Path, j gjson.Result) string {
+	var b strings.Builder
+	d.writeCanonical(&b, path, j)
+	return b.String()
+}
+
+func (d *differ) writeCanonical(b *strings.Builder, path Path, j gjson.Result) {
+	j = d.pr.redact(path, j)
+	switch {
+	case j.IsArray():
+		b.WriteString("[")
+		for i, item := range d.pr.elements(path, j) {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			d.writeCanonical(b, path.Append(i), item.value)
+		}
+		b.WriteString("]")
+	case j.IsObject():
+		ms := d.pr.members(path, j)
+		if d.opts.IgnoreKeyOrder {
+			sort.SliceStable(ms, func(x, y int) bool {
+				return ms[x].key.Str < ms[y].key.Str
+			})
+		}
+		b.WriteString("{")
+		for i, m := range ms {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(encodeString(m.key.Str))
+			b.WriteString(":")
+			d.writeCanonical(b, path.Append(m.key.Str), m.value)
+		}
+		b.WriteString("}")
+	case j.Type == gjson.Number:
+		b.WriteString(normalizeNumber(j.Raw))
+	default:
+		_, text := scalarToken(j)
+		b.WriteString(text)
+	}
+}
+
+// normalizeNumber returns the number literal lit in the form such as 15e-1
+// for 1.50, so that equal numbers have the same form. Unlike float64, it
+// keeps all the digits of big integers.
+func normalizeNumber(lit string) string {
+	lit = strings.TrimSpace(lit)
+	sign := ""
+	if strings.HasPrefix(lit, "-") {
+		sign, lit = "-", lit[1:]
+	}
+	exp := 0
+	if i := strings.IndexAny(lit, "eE"); i >= 0 {
+		e, err := strconv.Atoi(lit[i+1:])
+		if err != nil {
+			// The exponent is out of the range of int.
+			return sign + lit
+		}
+		exp, lit = e, lit[:i]
+	}
+	digits := lit
+	if i := strings.IndexByte(lit, '.'); i >= 0 {
+		digits = lit[:i] + lit[i+1:]
+		exp -= len(lit) - i - 1
+	}
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return "0"
+	}
+	n := len(digits)
+	digits = strings.TrimRight(digits, "0")
+	exp += n - len(digits)
+	return sign + digits + "e" + strconv.Itoa(exp)
+}
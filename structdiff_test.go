@@ -0,0 +1,163 @@
+package jpp_test
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tanishiking/jpp"
+)
+
+func TestDiff(t *testing.T) {
+	a := `{"name": "svc", "version": 1, "tags": ["a", "b", "c"], "users": [{"id": 1, "roles": ["admin"]}, {"id": 2}], "old": true}`
+	b := `{"version": 2, "name": "svc", "tags": ["a", "c", "d"], "users": [{"id": 0}, {"id": 1, "roles": ["admin", "dev"]}, {"id": 2}], "new": {"a": [1, 2]}}`
+	tests := []struct {
+		opts     jpp.DiffOptions
+		expected string
+	}{
+		{jpp.DiffOptions{}, `~ {  (key order changed)
+    … 1 unchanged member
+~   "version": 1 → 2
+    "tags": [
+      … 1 unchanged element
+-     "b"
+      … 1 unchanged element
++     "d"
+    ]
+    "users": [
+      {
+~       "id": 1 → 0
+-       "roles": ["admin"]
+      }
++     {
++       "id": 1,
++       "roles": ["admin", "dev"]
++     }
+      … 1 unchanged element
+    ]
+-   "old": true
++   "new": {
++     "a": [1, 2]
++   }
+  }`},
+		{jpp.DiffOptions{IgnoreKeyOrder: true, IDKey: "id"}, `  {
+    … 1 unchanged member
+~   "version": 1 → 2
+    "tags": [
+      … 1 unchanged element
+-     "b"
+      … 1 unchanged element
++     "d"
+    ]
+    "users": [
++     {"id": 0}
+      {
+        … 1 unchanged member
+        "roles": [
+          … 1 unchanged element
++         "dev"
+        ]
+      }
+      … 1 unchanged element
+    ]
+-   "old": true
++   "new": {
++     "a": [1, 2]
++   }
+  }`},
+	}
+	for _, test := range tests {
+		actual, differs, err := jpp.Diff(a, b, jpp.Options{Indent: "  ", Width: 40}, test.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !differs || actual != test.expected {
+			t.Errorf("%+v: got %v\n%v\nwant\n%v", test.opts, differs, actual, test.expected)
+		}
+	}
+}
+
+func TestDiff_same(t *testing.T) {
+	tests := []struct {
+		a, b           string
+		ignoreKeyOrder bool
+		differs        bool
+	}{
+		{`{"a": 1, "b": [1.0, "x"]}`, `{"a": 1, "b": [1, "x"]}`, false, false},
+		{`{"a": 1, "b": 2}`, `{"b": 2, "a": 1}`, false, true},
+		{`{"a": 1, "b": 2}`, `{"b": 2, "a": 1}`, true, false},
+		{`[null]`, `[false]`, true, true},
+		{`[12345678901234567891, 1e2, -0, 0.0150]`, `[12345678901234567891, 100, 0, 1.5E-2]`, false, false},
+		{`12345678901234567891`, `12345678901234567890`, false, true},
+		{`[1e400]`, `[1e401]`, false, true},
+		{`{"a": 1, "b": 1, "a": 2}`, `{"b": 1, "a": 2, "a": 1}`, false, true},
+	}
+	for _, test := range tests {
+		actual, differs, err := jpp.Diff(test.a, test.b, jpp.Options{Indent: "  ", Width: 80}, jpp.DiffOptions{IgnoreKeyOrder: test.ignoreKeyOrder})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if differs != test.differs || (!differs && actual != "") {
+			t.Errorf("%v and %v: got %v %q, want %v", test.a, test.b, differs, actual, test.differs)
+		}
+	}
+
+	if _, _, err := jpp.Diff(`{`, `{}`, jpp.Options{}, jpp.DiffOptions{}); err == nil {
+		t.Error("expected an error for invalid json")
+	}
+}
+
+func TestDiff_longArrays(t *testing.T) {
+	var xs, ys []string
+	for i := 0; i < 10000; i++ {
+		item := `{"id": ` + strconv.Itoa(i) + `, "tags": ["a", "b"]}`
+		xs = append(xs, item)
+		if i == 5000 {
+			item = `{"id": 5000, "tags": ["a", "c"]}`
+		}
+		ys = append(ys, item)
+	}
+	a, b := "["+strings.Join(xs, ",")+"]", "["+strings.Join(ys, ",")+"]"
+	actual, differs, err := jpp.Diff(a, b, jpp.Options{Indent: "  ", Width: 80}, jpp.DiffOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `  [
+    … 5000 unchanged elements
+    {
+      … 1 unchanged member
+      "tags": [
+        … 1 unchanged element
+~       "b" → "c"
+      ]
+    }
+    … 4999 unchanged elements
+  ]`
+	if !differs || actual != expected {
+		t.Errorf("got %v\n%v\nwant\n%v", differs, actual, expected)
+	}
+}
+
+func TestDiff_replacedArrays(t *testing.T) {
+	var xs, ys []string
+	for i := 0; i < 8000; i++ {
+		xs = append(xs, `"a`+strconv.Itoa(i)+`"`)
+		ys = append(ys, `"b`+strconv.Itoa(i)+`"`)
+	}
+	a, b := "["+strings.Join(xs, ",")+"]", "["+strings.Join(ys, ",")+"]"
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	actual, differs, err := jpp.Diff(a, b, jpp.Options{Indent: "  ", Width: 80}, jpp.DiffOptions{})
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !differs || strings.Count(actual, "\n~") != 8000 {
+		t.Errorf("got %v\n%v", differs, actual)
+	}
+	// The alignment of the elements takes linear space.
+	if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 64<<20 {
+		t.Errorf("allocated %v bytes", alloc)
+	}
+}
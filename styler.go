@@ -0,0 +1,162 @@
+package jpp
+
+import (
+	"strconv"
+	"strings"
+)
+
+// TokenKind is the kind of a token printed by jpp.
+type TokenKind int
+
+const (
+	// NullToken is `null`.
+	NullToken TokenKind = iota
+	// BoolToken is `true` or `false`.
+	BoolToken
+	// NumberToken is a number.
+	NumberToken
+	// StringToken is a string value.
+	StringToken
+	// KeyToken is a field name of an object.
+	KeyToken
+	// PunctuationToken is one of `[`, `]`, `{`, `}`, `,` and `:`.
+	PunctuationToken
+	// MarkerToken is `@json`, which marks the values expanded from strings
+	// by Options.ExpandStrings.
+	MarkerToken
+)
+
+// String returns the name of the token kind.
+func (k TokenKind) String() string {
+	switch k {
+	case NullToken:
+		return "null"
+	case BoolToken:
+		return "bool"
+	case NumberToken:
+		return "number"
+	case StringToken:
+		return "string"
+	case KeyToken:
+		return "key"
+	case PunctuationToken:
+		return "punctuation"
+	case MarkerToken:
+		return "marker"
+	default:
+		return "unknown"
+	}
+}
+
+// Path is the location of a value in a JSON document.
+// Each element is either an object key (string) or an array index (int).
+type Path []interface{}
+
+// String returns the path in dotted notation such as `$.items[3].name`.
+func (p Path) String() string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, elem := range p {
+		switch e := elem.(type) {
+		case int:
+			b.WriteString("[")
+			b.WriteString(strconv.Itoa(e))
+			b.WriteString("]")
+		case string:
+			if isIdentifier(e) {
+				b.WriteString(".")
+				b.WriteString(e)
+			} else {
+				b.WriteString("[")
+				b.WriteString(strconv.Quote(e))
+				b.WriteString("]")
+			}
+		}
+	}
+	return b.String()
+}
+
+// Pointer returns the path as a JSON Pointer (RFC 6901) such as `/items/3/name`.
+func (p Path) Pointer() string {
+	var b strings.Builder
+	for _, elem := range p {
+		b.WriteString("/")
+		switch e := elem.(type) {
+		case int:
+			b.WriteString(strconv.Itoa(e))
+		case string:
+			e = strings.Replace(e, "~", "~0", -1)
+			e = strings.Replace(e, "/", "~1", -1)
+			b.WriteString(e)
+		}
+	}
+	return b.String()
+}
+
+// Append returns a new path that has elem, an int index or a string key, at the end
+// without modifying the underlying array of p.
+func (p Path) Append(elem interface{}) Path {
+	path := make(Path, len(p), len(p)+1)
+	copy(path, p)
+	return append(path, elem)
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r == '_' || r == '$' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') {
+			continue
+		}
+		if i > 0 && '0' <= r && r <= '9' {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// Styler decorates tokens printed by jpp.
+// Style receives the kind of the token, the path to the value the token
+// belongs to, and the raw text of the token such as `"foo"`, `123` or `{`.
+// It returns the decorated text and its display width, which is used to
+// lay out the document.
+// Note that the decorated text must not contain any newlines.
+type Styler interface {
+	Style(kind TokenKind, path Path, text string) (styled string, width int)
+}
+
+// coloredKinds is the token kinds a ColorScheme colors.
+var coloredKinds = []TokenKind{NullToken, BoolToken, NumberToken, StringToken, KeyToken, MarkerToken}
+
+// colorOf returns the color for the token kind, or nil for punctuations.
+func (c *ColorScheme) colorOf(kind TokenKind) ColoredFormat {
+	switch kind {
+	case NullToken:
+		return c.Null
+	case BoolToken:
+		return c.Bool
+	case NumberToken:
+		return c.Number
+	case StringToken:
+		return c.String
+	case KeyToken:
+		return c.FieldName
+	case MarkerToken:
+		return c.Marker
+	default:
+		return nil
+	}
+}
+
+// Style implements Styler by coloring tokens according to the color scheme.
+// Punctuations are not colored.
+func (c *ColorScheme) Style(kind TokenKind, path Path, text string) (string, int) {
+	width := len([]rune(text))
+	color := c.colorOf(kind)
+	if color == nil {
+		return text, width
+	}
+	return color("%s", text), width
+}
@@ -0,0 +1,146 @@
+package jpp
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// SVGStyler is a Styler that wraps each token in a <tspan> element whose
+// class is "jpp-" followed by the kind of the token, such as "jpp-key".
+// The text of the token is XML-escaped.
+type SVGStyler struct{}
+
+// Style implements Styler.
+func (SVGStyler) Style(kind TokenKind, path Path, text string) (string, int) {
+	return fmt.Sprintf(`<tspan class="jpp-%v">%v</tspan>`, kind, htmlTextEscaper.Replace(text)), len([]rune(text))
+}
+
+// SVGOptions configures FormatSVG.
+type SVGOptions struct {
+	// ColorScheme is used to color tokens.
+	// DefaultScheme is used if it is nil.
+	ColorScheme *ColorScheme
+	// Padding is the space around the text in pixels.
+	Padding int
+	// FontSize is the font size in pixels. 14 is used if it is zero.
+	FontSize int
+	// FontFamily is the monospace font. "monospace" is used if it is empty.
+	FontFamily string
+	// Background is the background color. "#ffffff" is used if it is empty.
+	Background string
+	// Foreground is the color of uncolored text. "#000000" is used if it is empty.
+	Foreground string
+}
+
+var svgTag = regexp.MustCompile(`<[^>]*>`)
+
+var svgTextUnescaper = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">")
+
+// FormatSVG prettifies specified json string like Format and renders the
+// colored text as a self-contained SVG image, which looks like a snapshot
+// of the terminal. The line breaks are identical to the ones Format emits.
+// The Styler in opts is ignored.
+func FormatSVG(jsonStr string, opts Options, svgOpts SVGOptions) (string, error) {
+	opts.Styler = SVGStyler{}
+	res, err := Format(jsonStr, opts)
+	if err != nil {
+		return "", err
+	}
+
+	scheme := svgOpts.ColorScheme
+	if scheme == nil {
+		scheme = DefaultScheme
+	}
+	fontSize := svgOpts.FontSize
+	if fontSize == 0 {
+		fontSize = 14
+	}
+	fontFamily := svgOpts.FontFamily
+	if fontFamily == "" {
+		fontFamily = "monospace"
+	}
+	background := svgOpts.Background
+	if background == "" {
+		background = "#ffffff"
+	}
+	foreground := svgOpts.Foreground
+	if foreground == "" {
+		foreground = "#000000"
+	}
+
+	tabWidth := opts.TabWidth
+	if tabWidth <= 0 {
+		tabWidth = DefaultTabWidth
+	}
+	lines := strings.Split(res, "\n")
+	columns := 0
+	for i, line := range lines {
+		// Tabs are not rendered consistently in SVG.
+		line = expandIndentTabs(line, tabWidth)
+		lines[i] = line
+		plain := svgTextUnescaper.Replace(svgTag.ReplaceAllString(line, ""))
+		if n := len([]rune(plain)); n > columns {
+			columns = n
+		}
+	}
+	// Monospace glyphs are about 0.6em wide.
+	charWidth := float64(fontSize) * 0.6
+	lineHeight := float64(fontSize) * 1.4
+	padding := float64(svgOpts.Padding)
+	imageWidth := padding*2 + charWidth*float64(columns)
+	imageHeight := padding*2 + lineHeight*float64(len(lines))
+
+	var b bytes.Buffer
+	fmt.Fprintf(
+		&b,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%.1f" height="%.1f" viewBox="0 0 %.1f %.1f">`+"\n",
+		imageWidth, imageHeight, imageWidth, imageHeight,
+	)
+	b.WriteString("<style>\n")
+	fmt.Fprintf(
+		&b,
+		".jpp { font-family: %v; font-size: %vpx; fill: %v; white-space: pre; }\n",
+		htmlTextEscaper.Replace(fontFamily), fontSize, htmlTextEscaper.Replace(foreground),
+	)
+	for _, kind := range coloredKinds {
+		if css := styleOf(scheme.colorOf(kind)).svgCSS(); css != "" {
+			fmt.Fprintf(&b, ".jpp-%v { %v }\n", kind, css)
+		}
+	}
+	b.WriteString("</style>\n")
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%v"/>`+"\n", html.EscapeString(background))
+	b.WriteString(`<g class="jpp">` + "\n")
+	for i, line := range lines {
+		// y is the baseline of the line.
+		y := padding + lineHeight*float64(i) + float64(fontSize)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" xml:space="preserve">%v</text>`+"\n", padding, y, line)
+	}
+	b.WriteString("</g>\n</svg>")
+	return b.String(), nil
+}
+
+// expandIndentTabs replaces tabs in the indentation of the line with spaces
+// up to the next tab stop. Tabs never appear after the indentation since
+// they are escaped in JSON strings.
+func expandIndentTabs(line string, tabWidth int) string {
+	var b strings.Builder
+	col := 0
+	for i, r := range line {
+		switch r {
+		case '\t':
+			n := tabWidth - col%tabWidth
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+		case ' ':
+			b.WriteRune(r)
+			col++
+		default:
+			b.WriteString(line[i:])
+			return b.String()
+		}
+	}
+	return b.String()
+}
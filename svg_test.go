@@ -0,0 +1,70 @@
+package jpp_test
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tanishiking/jpp"
+)
+
+func TestFormatSVG(t *testing.T) {
+	jsonStr := `{"numbers": [[1,2,3,4,5], [6,7,8,9,10]], "tag": "<a>&"}`
+	opts := jpp.Options{Indent: "  ", Width: 15}
+	scheme := &jpp.ColorScheme{
+		Null:      jpp.NoColor,
+		Bool:      jpp.NoColor,
+		Number:    jpp.Red,
+		String:    jpp.Green,
+		FieldName: jpp.BoldBlue,
+	}
+	res, err := jpp.FormatSVG(jsonStr, opts, jpp.SVGOptions{ColorScheme: scheme, Padding: 10, FontSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The text of each <text> element is a line of the text output.
+	text, _ := jpp.Format(jsonStr, opts)
+	var lines []string
+	decoder := xml.NewDecoder(strings.NewReader(res))
+	inText := false
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid SVG: %v", err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if tok.Name.Local == "text" {
+				inText = true
+				lines = append(lines, "")
+			}
+		case xml.EndElement:
+			if tok.Name.Local == "text" {
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				lines[len(lines)-1] += string(tok)
+			}
+		}
+	}
+	if actual := strings.Join(lines, "\n"); actual != text {
+		t.Errorf("expected: %v, actual: %v", text, actual)
+	}
+
+	for _, expected := range []string{
+		`width="110.0" height="202.0"`,
+		".jpp-number { fill: #cd0000; }",
+		".jpp-key { fill: #0000ee; font-weight: bold; }",
+		`<text x="10.0" y="20.0" xml:space="preserve"><tspan class="jpp-punctuation">{</tspan></text>`,
+	} {
+		if !strings.Contains(res, expected) {
+			t.Errorf("expected %v to be contained in %v", expected, res)
+		}
+	}
+}